@@ -3,7 +3,9 @@ package main
 import (
 	"flag"
 	"os"
+	"time"
 
+	"github.com/finack/twinkle/internal/api"
 	"github.com/finack/twinkle/internal/config"
 	"github.com/finack/twinkle/internal/display"
 	"github.com/finack/twinkle/internal/metardata"
@@ -16,10 +18,14 @@ import (
 func main() {
 	debug := flag.Bool("debug", false, "Sets log level to debug")
 	configFile := flag.String("config", "config.yaml", "Path to configuration file")
+	backend := flag.String("backend", "", "Overrides the LED backend: ws281x, terminal or headless")
 
 	flag.Parse()
 
 	c := config.GetConfig(configFile)
+	if *backend != "" {
+		c.Backend = *backend
+	}
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
 	if *debug {
@@ -37,10 +43,28 @@ func main() {
 		Msg("Starting Twinkle!")
 
 	stopApplication := make(chan bool)
-	stopLedUpdate, ledChannel := display.UpdateRoutine(c)
-	stopMetarUpdate := metardata.FetchRoutine(c, ledChannel)
+	quiet, err := display.QuietHoursFromConfig(c)
+	if err != nil {
+		log.Fatal().Err(err).Caller().Msg("Invalid quiet_hours")
+	}
+	stopLedUpdate, renderer, monitor := display.UpdateRoutine(c, quiet)
+	state := metardata.NewState(c)
+	stopMetarUpdate := metardata.FetchRoutine(c, renderer, state)
+	stopAPI := api.ServeRoutine(c, state, monitor, quiet)
 
-	signals.CatchSignals(stopMetarUpdate, stopLedUpdate, stopApplication)
+	signals.CatchSignals(stopApplication, stopAPI, stopLedUpdate, stopMetarUpdate)
+	signals.CatchReload(func() {
+		next, err := config.LoadConfig(*configFile)
+		if err != nil {
+			log.Error().Err(err).Str("configFile", *configFile).Msg("Could not reload configuration, keeping the current one")
+			return
+		}
+		if err := display.ValidateHardware(next.Hardware); err != nil {
+			log.Error().Err(err).Str("configFile", *configFile).Msg("Invalid hardware in reloaded configuration, keeping the current one")
+			return
+		}
+		state.Reload(next, time.Now())
+	})
 
 	<-stopApplication
 }
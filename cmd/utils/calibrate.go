@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+
+	"golang.org/x/image/colornames"
+)
+
+// calibrationPattern fills the strip with a test pattern for judging color correction.
+type calibrationPattern struct {
+	name string
+	fill func(leds *display.Leds, ledCount int)
+}
+
+var calibrationPatterns = []calibrationPattern{
+	{"grey ramp — steps should look evenly spaced", func(leds *display.Leds, n int) {
+		for i := 0; i < n; i++ {
+			v := uint8(i * 255 / max(n-1, 1))
+			leds.Display(i, color.RGBA{R: v, G: v, B: v, A: 0xff})
+		}
+	}},
+	{"white — should look neutral, with no tint", func(leds *display.Leds, n int) {
+		fillStrip(leds, n, colornames.White)
+	}},
+	{"50% grey — should look neutral and half as bright", func(leds *display.Leds, n int) {
+		fillStrip(leds, n, color.RGBA{R: 128, G: 128, B: 128, A: 0xff})
+	}},
+	{"Limegreen / Yellowgreen alternating — should be distinct", func(leds *display.Leds, n int) {
+		for i := 0; i < n; i++ {
+			col := colornames.Limegreen
+			if i%2 == 1 {
+				col = colornames.Yellowgreen
+			}
+			leds.Display(i, col)
+		}
+	}},
+	{"VFR / MVFR / IFR / LIFR repeating", func(leds *display.Leds, n int) {
+		cats := []color.RGBA{colornames.Limegreen, colornames.Blue, colornames.Red, colornames.Mediumvioletred}
+		for i := 0; i < n; i++ {
+			leds.Display(i, cats[i%len(cats)])
+		}
+	}},
+}
+
+func fillStrip(leds *display.Leds, n int, col color.RGBA) {
+	for i := 0; i < n; i++ {
+		leds.Display(i, col)
+	}
+}
+
+// calibrate steps through test patterns while the gamma and white balance are
+// adjusted from stdin, then prints the resulting color_correction config.
+func calibrate(leds *display.Leds, c config.Config) {
+	cc := c.ColorCorrection
+	pattern := 0
+
+	fmt.Println("Commands: gamma|red|green|blue <value>, n (next pattern), p (previous), q (quit)")
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		leds.Correction = display.ColorCorrectionFromConfig(cc)
+		calibrationPatterns[pattern].fill(leds, c.LedCount)
+		leds.Render()
+
+		fmt.Printf("\n[%d/%d] %s\n       gamma=%.2f red=%.2f green=%.2f blue=%.2f\n> ",
+			pattern+1, len(calibrationPatterns), calibrationPatterns[pattern].name,
+			cc.Gamma, cc.Red, cc.Green, cc.Blue)
+		if !scanner.Scan() {
+			break
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "n", "next":
+			pattern = (pattern + 1) % len(calibrationPatterns)
+			continue
+		case "p", "prev":
+			pattern = (pattern + len(calibrationPatterns) - 1) % len(calibrationPatterns)
+			continue
+		case "q", "quit":
+			printCorrection(cc)
+			return
+		}
+
+		if len(fields) != 2 {
+			fmt.Println("expected a setting and a value, e.g. \"gamma 2.2\"")
+			continue
+		}
+		v, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || v <= 0 {
+			fmt.Printf("invalid value %q\n", fields[1])
+			continue
+		}
+
+		switch fields[0] {
+		case "gamma":
+			cc.Gamma = v
+		case "red":
+			cc.Red = v
+		case "green":
+			cc.Green = v
+		case "blue":
+			cc.Blue = v
+		default:
+			fmt.Printf("unknown setting %q\n", fields[0])
+		}
+	}
+	printCorrection(cc)
+}
+
+func printCorrection(cc config.ColorCorrectionConfig) {
+	fmt.Printf("\nAdd to config.yaml:\n\ncolor_correction:\n  gamma: %.2f\n  red: %.2f\n  green: %.2f\n  blue: %.2f\n",
+		cc.Gamma, cc.Red, cc.Green, cc.Blue)
+}
@@ -27,8 +27,9 @@ const (
 	holdTime   = 8 * time.Second
 )
 
-// vfrWindSteps shows all map LEDs as VFR at each key wind speed, stepping on Enter.
+// vfrWindSteps shows all station LEDs as VFR at each key wind speed, stepping on Enter.
 func vfrWindSteps(leds *display.Leds, c config.Config) {
+	theme := metardata.ThemeFromConfig(c.Themes[c.Theme])
 	steps := []float64{0, 10, 15, 20, 25, 32, 40}
 	labels := []string{
 		"0 kt — calm (pure VFR green)",
@@ -42,11 +43,13 @@ func vfrWindSteps(leds *display.Leds, c config.Config) {
 
 	scanner := bufio.NewScanner(os.Stdin)
 	for i, kt := range steps {
-		col := metardata.FlightColor("VFR", kt, c.WindLowKt, c.WindHighKt)
-		for ledNum := range c.Leds {
-			leds.Display(ledNum, col)
+		col := theme.FlightColor("VFR", kt, c.WindLowKt, c.WindHighKt)
+		for ledNum, id := range c.Leds {
+			if config.IsStation(id) {
+				leds.Display(ledNum, col)
+			}
 		}
-		leds.Ws.Render()
+		leds.Render()
 		fmt.Printf("\n[%d/%d] %s\n       R=%d G=%d B=%d\nPress Enter for next...",
 			i+1, len(steps), labels[i], col.R, col.G, col.B)
 		scanner.Scan()
@@ -56,29 +59,31 @@ func vfrWindSteps(leds *display.Leds, c config.Config) {
 // showGradient fills the strip with a wind-speed gradient: each category gets an
 // equal slice of LEDs, ranging from calm (left) to stormy (right).
 func showGradient(leds *display.Leds, c config.Config) {
+	theme := metardata.ThemeFromConfig(c.Themes[c.Theme])
 	ledsPerCat := c.LedCount / len(catNames)
 	for catIdx, cat := range catNames {
 		for pos := 0; pos < ledsPerCat; pos++ {
 			kt := float64(pos) / float64(ledsPerCat-1) * maxKt
-			leds.Display(catIdx*ledsPerCat+pos, metardata.FlightColor(cat, kt, c.WindLowKt, c.WindHighKt))
+			leds.Display(catIdx*ledsPerCat+pos, theme.FlightColor(cat, kt, c.WindLowKt, c.WindHighKt))
 		}
 	}
 	for i := len(catNames) * ledsPerCat; i < c.LedCount; i++ {
 		leds.Display(i, color.RGBA{})
 	}
-	leds.Ws.Render()
+	leds.Render()
 }
 
 // sweepCategory ramps all LEDs through 0→maxKt→0 for a single flight category.
 func sweepCategory(leds *display.Leds, c config.Config, cat string) {
+	theme := metardata.ThemeFromConfig(c.Themes[c.Theme])
 	ramp := func(start, end int) {
 		for step := start; step != end; step += sign(end - start) {
 			kt := float64(step) / sweepSteps * maxKt
-			col := metardata.FlightColor(cat, kt, c.WindLowKt, c.WindHighKt)
+			col := theme.FlightColor(cat, kt, c.WindLowKt, c.WindHighKt)
 			for i := 0; i < c.LedCount; i++ {
 				leds.Display(i, col)
 			}
-			leds.Ws.Render()
+			leds.Render()
 			time.Sleep(sweepDelay)
 		}
 	}
@@ -98,15 +103,43 @@ func sign(n int) int {
 func main() {
 	configFile := flag.String("config", "config.yaml", "Path to configuration file")
 	steps := flag.Bool("steps", false, "Step through VFR wind states on the real map layout")
+	calibrateColors := flag.Bool("calibrate", false, "Interactively tune gamma and white balance")
+	tuneLeds := flag.Bool("tune", false, "Interactively tune per-LED and per-group brightness and color")
+	backend := flag.String("backend", "", "Overrides the LED backend: ws281x, terminal or headless")
+	themeName := flag.String("theme", "", "Previews a theme instead of the configured one")
+	planDate := flag.String("plan", "", "Prints the brightness plan for a day, e.g. 2024-06-21, without touching the LEDs")
+	snapshotFile := flag.String("snapshot", "", "Writes the live map to a .png, or a .gif of -seconds, without touching the LEDs")
+	seconds := flag.Int("seconds", 10, "Seconds of LED states recorded for a -snapshot GIF")
 	flag.Parse()
 
 	c := config.GetConfig(configFile)
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	if *backend != "" {
+		c.Backend = *backend
+	}
+	if *themeName != "" {
+		if _, ok := c.Themes[*themeName]; !ok {
+			log.Fatal().Str("theme", *themeName).Msg("Theme not found in themes or presets")
+		}
+		c.Theme = *themeName
+	}
+
+	if *planDate != "" {
+		plan(c, *planDate)
+		return
+	}
+
+	if *snapshotFile != "" {
+		snapshot(c, *snapshotFile, *seconds)
+		return
+	}
 
-	leds, err := display.New(c.Brightness, c.LedCount)
+	leds, err := display.Open(c)
 	if err != nil {
 		log.Fatal().Err(err).Caller().Msg("Could not setup LEDs")
 	}
+	leds.Correction = display.ColorCorrectionFromConfig(c.ColorCorrection)
+	leds.Calibration = display.CalibrationFromConfig(c)
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
@@ -116,6 +149,18 @@ func main() {
 		os.Exit(0)
 	}()
 
+	if *calibrateColors {
+		calibrate(leds, c)
+		leds.Clear()
+		return
+	}
+
+	if *tuneLeds {
+		tune(leds, c)
+		leds.Clear()
+		return
+	}
+
 	if *steps {
 		log.Info().
 			Float64("lowKt", c.WindLowKt).
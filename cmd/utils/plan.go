@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+
+	"github.com/rs/zerolog/log"
+)
+
+// plan prints the sun curve's points on date (YYYY-MM-DD) in the configured
+// locale, then the level and channel brightness every half hour. Sources that
+// can't be read ahead of time, like a light sensor, are left out.
+func plan(c config.Config, date string) {
+	loc, err := time.LoadLocation(c.Locale)
+	if err != nil {
+		log.Fatal().Err(err).Str("locale", c.Locale).Msg("Could not load timezone")
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Plan date should look like 2024-06-21")
+	}
+
+	bc := c.BrightnessControl
+	sun, brightness, err := planBrightness(c.Latitude, c.Longitude, bc)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid brightness_control")
+	}
+
+	fmt.Printf("Brightness plan for %s in %s (twilight %s, %g min ramps, %s)\n\n",
+		day.Format("Mon 2006-01-02"), loc, bc.Sun.Twilight, bc.Sun.RampMin, bc.Sun.Easing)
+	for _, p := range sun.Plan(day) {
+		fmt.Printf("  %s  %-18s level %.2f\n", p.Time.Format("15:04"), p.Label, p.Level)
+	}
+
+	fmt.Printf("\n  time   sun   level  brightness\n")
+	end := day.AddDate(0, 0, 1)
+	for t := day; t.Before(end); t = t.Add(30 * time.Minute) {
+		sunLevel, _ := sun.Level(t)
+		level, ok, _ := brightness.Update(t)
+		if !ok {
+			fmt.Printf("  %s  %.2f  -\n", t.Format("15:04"), sunLevel)
+			continue
+		}
+		var channels []string
+		for _, cc := range c.Hardware.Channels {
+			channels = append(channels, fmt.Sprint(display.ScaleBrightness(level, cc.Brightness, cc.NightBrightness)))
+		}
+		fmt.Printf("  %s  %.2f  %.2f   %s\n", t.Format("15:04"), sunLevel, level, strings.Join(channels, " "))
+	}
+}
+
+// planBrightness builds the sun curve and the brightness from bc's sun and
+// schedule sources, unsmoothed, leaving out the ones read live.
+func planBrightness(latitude, longitude float64, bc config.BrightnessConfig) (*display.SunCurve, *display.Brightness, error) {
+	sun, err := display.NewSunCurve(latitude, longitude, bc.Sun)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sun: %w", err)
+	}
+	bc.SmoothingS = 0
+	bc.Sources = slices.DeleteFunc(slices.Clone(bc.Sources), func(src config.BrightnessSourceConfig) bool {
+		return src.Type != config.BrightnessSun && src.Type != config.BrightnessSchedule
+	})
+	brightness, err := display.BrightnessFromConfig(bc, sun)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sun, brightness, nil
+}
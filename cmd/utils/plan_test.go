@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+func TestPlanBrightness_LeavesOutIlluminance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "in_illuminance_input")
+	if err := os.WriteFile(path, []byte("100000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bc := config.BrightnessConfig{
+		Sources: []config.BrightnessSourceConfig{
+			{Type: config.BrightnessSun},
+			{Type: config.BrightnessIlluminance, Path: path, Scale: 1, DarkLux: 1, BrightLux: 1000},
+		},
+		Combine:    "max",
+		SmoothingS: 60,
+		Sun:        config.SunConfig{Twilight: "sunrise", RampMin: 60, Easing: "linear"},
+	}
+
+	_, brightness, err := planBrightness(37.9884, -122.0578, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The sensor reads full daylight, but the plan should follow the sun alone.
+	level, ok, err := brightness.Update(time.Date(2024, 6, 21, 2, 0, 0, 0, loc))
+	if err != nil || !ok || level != 0 {
+		t.Errorf("2am: got level %v ok %v err %v, want 0 from the sun", level, ok, err)
+	}
+	if len(bc.Sources) != 2 {
+		t.Errorf("planBrightness changed the config's sources: %+v", bc.Sources)
+	}
+}
+
+func TestPlanBrightness_OnlyIlluminance(t *testing.T) {
+	bc := config.BrightnessConfig{
+		Sources: []config.BrightnessSourceConfig{{Type: config.BrightnessIlluminance, Path: "/nonexistent"}},
+		Combine: "max",
+		Sun:     config.SunConfig{Twilight: "sunrise", RampMin: 60, Easing: "linear"},
+	}
+	_, brightness, err := planBrightness(37.9884, -122.0578, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := brightness.Update(time.Now()); ok {
+		t.Error("expected no level to plan from a light sensor alone")
+	}
+}
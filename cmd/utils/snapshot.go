@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+	"github.com/finack/twinkle/internal/metardata"
+	"github.com/finack/twinkle/internal/simulator"
+
+	"github.com/rs/zerolog/log"
+)
+
+// metarWait is how long snapshot waits for the first METARs.
+const metarWait = 30 * time.Second
+
+// snapshot runs the display headless from c, fetching METARs as the server
+// would, and writes the map to out: a PNG of the LEDs once the first METARs
+// have faded in, or a GIF of the seconds after that.
+func snapshot(c config.Config, out string, seconds int) {
+	gif := strings.EqualFold(filepath.Ext(out), ".gif")
+	if !gif && !strings.EqualFold(filepath.Ext(out), ".png") {
+		log.Fatal().Str("snapshot", out).Msg("Snapshot should end in .png or .gif")
+	}
+
+	c.Backend = config.BackendHeadless
+	c.QuietHours.Windows = nil
+	c.Simulator.HistoryS = max(c.Simulator.HistoryS, seconds)
+
+	quiet, err := display.QuietHoursFromConfig(c)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid quiet_hours")
+	}
+	_, renderer, monitor := display.UpdateRoutine(c, quiet)
+	state := metardata.NewState(c)
+	metardata.FetchRoutine(c, renderer, state)
+
+	deadline := time.Now().Add(metarWait)
+	for len(state.Metars()) == 0 {
+		if time.Now().After(deadline) {
+			log.Fatal().Dur("waited", metarWait).Msg("No METARs to snapshot")
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	time.Sleep(time.Duration(c.Transition.DurationMS) * time.Millisecond)
+
+	layout := simulator.NewLayout(c.Simulator, c.LedCount, c.Leds, simulator.Coords(state.Metars()))
+	_, theme := state.Theme()
+	opts := simulator.DefaultOptions(c.Simulator, theme)
+
+	f, err := os.Create(out)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not create snapshot")
+	}
+	defer f.Close()
+
+	if gif {
+		start := time.Now()
+		log.Info().Int("seconds", seconds).Msg("Recording snapshot")
+		time.Sleep(time.Duration(seconds) * time.Second)
+		snaps := monitor.History(start)
+		if len(snaps) == 0 {
+			snaps = []display.Snapshot{monitor.Snapshot()}
+		}
+		err = simulator.WriteGIF(f, layout, snaps, opts)
+	} else {
+		err = simulator.WritePNG(f, layout, monitor.Snapshot(), opts)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not write snapshot")
+	}
+	log.Info().Str("snapshot", out).Msg("Wrote snapshot")
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+)
+
+// tuneTarget is the LED or group being calibrated.
+type tuneTarget struct {
+	led   int
+	group string // set when tuning a group
+}
+
+func (t tuneTarget) String() string {
+	if t.group != "" {
+		return "group " + t.group
+	}
+	return "LED " + strconv.Itoa(t.led)
+}
+
+func (t tuneTarget) leds(c config.Config) []int {
+	if t.group != "" {
+		return c.Groups[t.group].LedNumbers()
+	}
+	return []int{t.led}
+}
+
+// tune shows a reference color on every LED while per-LED and per-group
+// calibrations are adjusted from stdin, then writes them to the calibration file.
+func tune(leds *display.Leds, c config.Config) {
+	cal := c.Calibration
+	if cal.Leds == nil {
+		cal.Leds = make(map[int]config.LedCalibration)
+	}
+	if cal.Groups == nil {
+		cal.Groups = make(map[string]config.LedCalibration)
+	}
+	reference := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	target := tuneTarget{}
+	saved := true
+
+	fmt.Println("Commands: led <n>, group <name>, brightness|red|green|blue <value>, color <hex>,")
+	fmt.Println("          blink (find the selection), reset, w (write), q (quit)")
+
+	show := func() {
+		c.Calibration = cal
+		leds.Calibration = display.CalibrationFromConfig(c)
+		fillStrip(leds, c.LedCount, reference)
+		leds.Render()
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		show()
+		lc := targetCalibration(cal, target)
+		fmt.Printf("\n%s: brightness=%.2f red=%d green=%d blue=%d\n> ",
+			target, lc.Multiplier(), lc.Red, lc.Green, lc.Blue)
+		if !scanner.Scan() {
+			break
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "blink":
+			for i := 0; i < 3; i++ {
+				for _, num := range target.leds(c) {
+					leds.Display(num, color.RGBA{})
+				}
+				leds.Render()
+				time.Sleep(300 * time.Millisecond)
+				show()
+				time.Sleep(300 * time.Millisecond)
+			}
+			continue
+		case "reset":
+			setTargetCalibration(&cal, target, config.LedCalibration{})
+			saved = false
+			continue
+		case "w", "write":
+			if err := config.SaveCalibration(c.CalibrationFile, cal); err != nil {
+				fmt.Printf("could not write %s: %v\n", c.CalibrationFile, err)
+				continue
+			}
+			fmt.Printf("wrote %s\n", c.CalibrationFile)
+			saved = true
+			continue
+		case "q", "quit":
+			if !saved {
+				fmt.Println("quitting without writing the changes")
+			}
+			return
+		}
+
+		if len(fields) != 2 {
+			fmt.Println("expected a setting and a value, e.g. \"brightness 1.3\"")
+			continue
+		}
+
+		switch fields[0] {
+		case "led":
+			n, err := strconv.Atoi(fields[1])
+			if err != nil || n < 0 || n >= c.LedCount {
+				fmt.Printf("LED should be between 0 and %d\n", c.LedCount-1)
+				continue
+			}
+			target = tuneTarget{led: n}
+		case "group":
+			if _, ok := c.Groups[fields[1]]; !ok {
+				fmt.Printf("unknown group %q, use one of %v\n", fields[1], slices.Sorted(maps.Keys(c.Groups)))
+				continue
+			}
+			target = tuneTarget{group: fields[1]}
+		case "color":
+			col, err := config.ParseHexColor(fields[1])
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			reference = col
+		case "brightness":
+			v, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil || v < 0 {
+				fmt.Printf("invalid brightness %q\n", fields[1])
+				continue
+			}
+			lc.Brightness = &v
+			setTargetCalibration(&cal, target, lc)
+			saved = false
+		case "red", "green", "blue":
+			v, err := strconv.Atoi(fields[1])
+			if err != nil || v < -255 || v > 255 {
+				fmt.Printf("offset should be between -255 and 255, got %q\n", fields[1])
+				continue
+			}
+			switch fields[0] {
+			case "red":
+				lc.Red = v
+			case "green":
+				lc.Green = v
+			case "blue":
+				lc.Blue = v
+			}
+			setTargetCalibration(&cal, target, lc)
+			saved = false
+		default:
+			fmt.Printf("unknown setting %q\n", fields[0])
+		}
+	}
+}
+
+func targetCalibration(cal config.CalibrationConfig, t tuneTarget) config.LedCalibration {
+	if t.group != "" {
+		return cal.Groups[t.group]
+	}
+	return cal.Leds[t.led]
+}
+
+// setTargetCalibration stores lc for the target, dropping calibrations that no
+// longer change anything so the file stays short.
+func setTargetCalibration(cal *config.CalibrationConfig, t tuneTarget, lc config.LedCalibration) {
+	if lc.Multiplier() == 1 {
+		lc.Brightness = nil
+	}
+	empty := lc == config.LedCalibration{}
+	switch {
+	case t.group != "" && empty:
+		delete(cal.Groups, t.group)
+	case t.group != "":
+		cal.Groups[t.group] = lc
+	case empty:
+		delete(cal.Leds, t.led)
+	default:
+		cal.Leds[t.led] = lc
+	}
+}
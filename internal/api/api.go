@@ -0,0 +1,315 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+	"github.com/finack/twinkle/internal/metardata"
+	"github.com/finack/twinkle/internal/simulator"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ServeRoutine runs the HTTP API and the browser simulator on c.HTTPAddr until
+// done is signalled. When no address is configured the API is disabled, but done
+// must still be signalled.
+func ServeRoutine(c config.Config, state *metardata.State, monitor *display.Monitor, quiet *display.QuietHours) chan bool {
+	done := make(chan bool)
+
+	if c.HTTPAddr == "" {
+		go func() { <-done }()
+		return done
+	}
+
+	srv := &http.Server{Addr: c.HTTPAddr, Handler: newHandler(state, monitor, quiet, simulator.Handler(c, state, monitor))}
+
+	go func() {
+		log.Info().Str("addr", c.HTTPAddr).Msg("Starting HTTP API")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Str("addr", c.HTTPAddr).Msg("HTTP API stopped")
+		}
+	}()
+
+	go func() {
+		<-done
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	return done
+}
+
+// newHandler routes the API; sim serves the simulator under /map, monitor the
+// display status and quiet the quiet hours, unless nil.
+func newHandler(state *metardata.State, monitor *display.Monitor, quiet *display.QuietHours, sim http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	if sim != nil {
+		mux.Handle("GET /map", sim)
+		mux.Handle("GET /map/", sim)
+	}
+	if monitor != nil {
+		mux.HandleFunc("GET /api/status", getStatus(monitor, state))
+	}
+	if quiet != nil {
+		mux.HandleFunc("GET /api/quiet", getQuiet(quiet))
+		mux.HandleFunc("PUT /api/quiet", putQuiet(quiet))
+		mux.HandleFunc("DELETE /api/quiet", deleteQuiet(quiet))
+	}
+	mux.HandleFunc("GET /api/profile", getProfile(state))
+	mux.HandleFunc("PUT /api/profile", putProfile(state))
+	mux.HandleFunc("GET /api/stations", getStations(state))
+	mux.HandleFunc("GET /api/changes", getChanges(state))
+	mux.HandleFunc("GET /api/mode", getMode(state))
+	mux.HandleFunc("PUT /api/mode", putMode(state))
+	mux.HandleFunc("GET /api/theme", getTheme(state))
+	mux.HandleFunc("PUT /api/theme", putTheme(state))
+	return mux
+}
+
+type stationResponse struct {
+	StationID       string   `json:"station"`
+	ObservationTime string   `json:"observation_time"`
+	MetarType       string   `json:"metar_type"`
+	Corrected       bool     `json:"corrected"`
+	FlightCategory  string   `json:"flight_category"`
+	Degraded        []string `json:"degraded,omitempty"`
+	RawText         string   `json:"raw_text"`
+}
+
+func getStations(state *metardata.State) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stations := []stationResponse{}
+		for _, m := range state.Metars() {
+			stations = append(stations, stationResponse{
+				StationID:       m.StationID,
+				ObservationTime: m.ObservationTime,
+				MetarType:       m.MetarType,
+				Corrected:       m.IsCorrected(),
+				FlightCategory:  m.FlightCategory,
+				Degraded:        m.DegradedReasons(),
+				RawText:         m.RawText,
+			})
+		}
+		writeJSON(w, http.StatusOK, stations)
+	}
+}
+
+func getChanges(state *metardata.State) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		changes := state.Changes()
+		if changes == nil {
+			changes = []metardata.Change{}
+		}
+		writeJSON(w, http.StatusOK, changes)
+	}
+}
+
+type powerResponse struct {
+	RequestedMA float64 `json:"requested_ma"`
+	DrawMA      float64 `json:"draw_ma"`
+	BudgetMA    float64 `json:"budget_ma,omitempty"`
+	Scale       float64 `json:"scale"`
+	Limited     bool    `json:"limited"`
+}
+
+type statusResponse struct {
+	Power    powerResponse       `json:"power"`
+	Degraded map[string][]string `json:"degraded"` // reasons by station, for stations reporting problems
+}
+
+func getStatus(monitor *display.Monitor, state *metardata.State) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := monitor.Power()
+		writeJSON(w, http.StatusOK, statusResponse{
+			Power: powerResponse{
+				RequestedMA: math.Round(p.RequestedMA),
+				DrawMA:      math.Round(p.DrawMA),
+				BudgetMA:    p.BudgetMA,
+				Scale:       p.Scale,
+				Limited:     p.Limited(),
+			},
+			Degraded: degradedStations(state.Metars()),
+		})
+	}
+}
+
+// degradedStations returns the degraded reasons of each station that has any.
+func degradedStations(metars []metardata.Metar) map[string][]string {
+	degraded := make(map[string][]string)
+	for _, m := range metars {
+		if reasons := m.DegradedReasons(); len(reasons) > 0 {
+			degraded[m.StationID] = reasons
+		}
+	}
+	return degraded
+}
+
+type quietOverrideResponse struct {
+	On    bool      `json:"on"`
+	Until time.Time `json:"until"`
+}
+
+type quietResponse struct {
+	Scheduled bool                   `json:"scheduled"`
+	Level     float64                `json:"level"`
+	Override  *quietOverrideResponse `json:"override,omitempty"`
+}
+
+func quietStatus(quiet *display.QuietHours) quietResponse {
+	s := quiet.Status(time.Now())
+	resp := quietResponse{Scheduled: s.Scheduled, Level: math.Round(s.Level*100) / 100}
+	if s.Override != nil {
+		resp.Override = &quietOverrideResponse{On: s.Override.On, Until: s.Override.Until}
+	}
+	return resp
+}
+
+func getQuiet(quiet *display.QuietHours) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, quietStatus(quiet))
+	}
+}
+
+type quietRequest struct {
+	On      bool    `json:"on"`
+	Minutes float64 `json:"minutes"`
+}
+
+func putQuiet(quiet *display.QuietHours) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req quietRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if req.Minutes <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("minutes should be more than 0"))
+			return
+		}
+		o := quiet.Override(req.On, time.Duration(req.Minutes*float64(time.Minute)), time.Now())
+		log.Info().Bool("on", o.On).Time("until", o.Until).Msg("Overrode quiet hours")
+		writeJSON(w, http.StatusOK, quietStatus(quiet))
+	}
+}
+
+func deleteQuiet(quiet *display.QuietHours) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		quiet.ClearOverride(time.Now())
+		log.Info().Msg("Cleared quiet hours override")
+		writeJSON(w, http.StatusOK, quietStatus(quiet))
+	}
+}
+
+type profileResponse struct {
+	Profile  string   `json:"profile"`
+	Profiles []string `json:"profiles"`
+}
+
+func getProfile(state *metardata.State) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, _ := state.Profile()
+		writeJSON(w, http.StatusOK, profileResponse{Profile: name, Profiles: state.Profiles()})
+	}
+}
+
+type profileRequest struct {
+	Profile string `json:"profile"`
+}
+
+func putProfile(state *metardata.State) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req profileRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := state.SetProfile(req.Profile); err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		log.Info().Str("profile", req.Profile).Msg("Selected profile")
+		writeJSON(w, http.StatusOK, profileResponse{Profile: req.Profile, Profiles: state.Profiles()})
+	}
+}
+
+type modeResponse struct {
+	Mode  string   `json:"mode"`
+	Modes []string `json:"modes"`
+}
+
+func getMode(state *metardata.State) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, modeResponse{Mode: state.Mode(), Modes: config.Modes})
+	}
+}
+
+type modeRequest struct {
+	Mode string `json:"mode"`
+}
+
+func putMode(state *metardata.State) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req modeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := state.SetMode(req.Mode); err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		log.Info().Str("mode", req.Mode).Msg("Selected display mode")
+		writeJSON(w, http.StatusOK, modeResponse{Mode: req.Mode, Modes: config.Modes})
+	}
+}
+
+type themeResponse struct {
+	Theme  string   `json:"theme"`
+	Themes []string `json:"themes"`
+}
+
+func getTheme(state *metardata.State) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, _ := state.Theme()
+		writeJSON(w, http.StatusOK, themeResponse{Theme: name, Themes: state.Themes()})
+	}
+}
+
+type themeRequest struct {
+	Theme string `json:"theme"`
+}
+
+func putTheme(state *metardata.State) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req themeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := state.SetTheme(req.Theme); err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		log.Info().Str("theme", req.Theme).Msg("Selected theme")
+		writeJSON(w, http.StatusOK, themeResponse{Theme: req.Theme, Themes: state.Themes()})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error().Err(err).Msg("Could not encode HTTP response")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
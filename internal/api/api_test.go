@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+	"github.com/finack/twinkle/internal/metardata"
+)
+
+func newTestState() *metardata.State {
+	return metardata.NewState(config.Config{
+		Profile:  "ppl_day",
+		Profiles: map[string]config.Profile{"ppl_day": {}, "student_solo": {}},
+	})
+}
+
+func TestGetProfile(t *testing.T) {
+	h := newHandler(newTestState(), nil, nil, nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/profile", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", rec.Code)
+	}
+	var resp profileResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Profile != "ppl_day" || len(resp.Profiles) != 2 {
+		t.Errorf("got %+v", resp)
+	}
+}
+
+func TestPutProfile(t *testing.T) {
+	state := newTestState()
+	h := newHandler(state, nil, nil, nil)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/profile", strings.NewReader(`{"profile":"student_solo"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if name, _ := state.Profile(); name != "student_solo" {
+		t.Errorf("profile: got %q, want student_solo", name)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/profile", strings.NewReader(`{"profile":"nope"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown profile status: got %d, want 404", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/profile", strings.NewReader(`not json`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("bad body status: got %d, want 400", rec.Code)
+	}
+}
+
+func TestPutMode(t *testing.T) {
+	state := newTestState()
+	h := newHandler(state, nil, nil, nil)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/mode", strings.NewReader(`{"mode":"pressure_tendency"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if state.Mode() != config.ModePressureTendency {
+		t.Errorf("mode: got %q, want %q", state.Mode(), config.ModePressureTendency)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/mode", strings.NewReader(`{"mode":"radar"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown mode status: got %d, want 404", rec.Code)
+	}
+}
+
+func TestGetStationsAndChanges(t *testing.T) {
+	h := newHandler(newTestState(), nil, nil, nil)
+
+	for _, path := range []string{"/api/stations", "/api/changes"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s status: got %d, want 200", path, rec.Code)
+		}
+		if body := strings.TrimSpace(rec.Body.String()); body != "[]" {
+			t.Errorf("%s body: got %q, want empty list", path, body)
+		}
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	monitor := display.NewMonitor(config.HardwareConfig{}, 0)
+	h := newHandler(newTestState(), monitor, nil, nil)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", rec.Code)
+	}
+	var resp statusResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Power.Limited {
+		t.Errorf("got %+v, want an unlimited estimate before the first frame", resp)
+	}
+	if resp.Degraded == nil || len(resp.Degraded) != 0 {
+		t.Errorf("degraded: got %v, want an empty map before the first fetch", resp.Degraded)
+	}
+
+	rec = httptest.NewRecorder()
+	newHandler(newTestState(), nil, nil, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("without a monitor: got %d, want 404", rec.Code)
+	}
+}
+
+func TestDegradedStations(t *testing.T) {
+	got := degradedStations([]metardata.Metar{
+		{StationID: "KSFO"},
+		{StationID: "KOAK", MaintenanceIndicatorOn: "TRUE"},
+	})
+	if len(got) != 1 || !slices.Equal(got["KOAK"], []string{"maintenance indicator on"}) {
+		t.Errorf("got %v, want only KOAK with its maintenance indicator", got)
+	}
+}
+
+func TestGetAndPutTheme(t *testing.T) {
+	state := metardata.NewState(config.Config{Theme: "default", Themes: config.ThemePresets})
+	h := newHandler(state, nil, nil, nil)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/theme", strings.NewReader(`{"theme":"deuteranopia"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/theme", nil))
+	var resp themeResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Theme != "deuteranopia" || len(resp.Themes) != len(config.ThemePresets) {
+		t.Errorf("got %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/theme", strings.NewReader(`{"theme":"neon"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown theme status: got %d, want 404", rec.Code)
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	quiet, err := display.QuietHoursFromConfig(config.Config{Locale: "UTC", QuietHours: config.QuietHoursConfig{FadeS: 60}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newHandler(newTestState(), nil, quiet, nil)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/quiet", strings.NewReader(`{"on":false,"minutes":60}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var resp quietResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Scheduled || resp.Override == nil || resp.Override.On {
+		t.Errorf("got %+v, want an off override", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/quiet", nil))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/quiet", nil))
+	resp = quietResponse{}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Override != nil {
+		t.Errorf("got %+v, want the override cleared", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/quiet", strings.NewReader(`{"on":true}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("without minutes: got %d, want 400", rec.Code)
+	}
+}
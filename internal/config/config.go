@@ -1,8 +1,16 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v2"
@@ -11,42 +19,1061 @@ import (
 type Config struct {
 	Leds              map[int]string `yaml:"leds,omitempty"`
 	Stations          map[string]int
-	LedCount          int     `yaml:"led_count,omitempty"`
-	Brightness        int     `yaml:"brightness,omitempty"`
-	NightBrightness   int     `yaml:"night_brightness,omitempty"`
-	MetarRefreshRateS int     `yaml:"metar_refresh_rate_s,omitempty"` // seconds
-	LedRefreshRateMS  int     `yaml:"led_refresh_rate_ms,omitempty"`  // milliseconds
-	Latitude          float64 `yaml:"latitude,omitempty"`
-	Longitude         float64 `yaml:"longitude,omitempty"`
-	Locale            string  `yaml:"locale,omitempty"`
-	WindLowKt         float64 `yaml:"wind_low_kt,omitempty"`
-	WindHighKt        float64 `yaml:"wind_high_kt,omitempty"`
+	LedCount          int                    `yaml:"led_count,omitempty"` // LEDs on the first channel; the total across segments once loaded
+	Brightness        int                    `yaml:"brightness,omitempty"`
+	NightBrightness   int                    `yaml:"night_brightness,omitempty"`
+	MetarRefreshRateS int                    `yaml:"metar_refresh_rate_s,omitempty"` // seconds
+	LedRefreshRateMS  int                    `yaml:"led_refresh_rate_ms,omitempty"`  // milliseconds; default for AnimationTickMS
+	AnimationTickMS   int                    `yaml:"animation_tick_ms,omitempty"`    // milliseconds between animation steps
+	Latitude          float64                `yaml:"latitude,omitempty"`
+	Longitude         float64                `yaml:"longitude,omitempty"`
+	Locale            string                 `yaml:"locale,omitempty"`
+	WindLowKt         float64                `yaml:"wind_low_kt,omitempty"`
+	WindHighKt        float64                `yaml:"wind_high_kt,omitempty"`
+	Lightning         LightningConfig        `yaml:"lightning,omitempty"`
+	Precipitation     PrecipConfig           `yaml:"precipitation,omitempty"`
+	Profile           string                 `yaml:"profile,omitempty"` // selected personal minimums profile; empty shows FAA categories
+	Profiles          map[string]Profile     `yaml:"profiles,omitempty"`
+	Runways           map[string][]float64   `yaml:"runways,omitempty"`   // station ID to runway headings in degrees
+	HTTPAddr          string                 `yaml:"http_addr,omitempty"` // e.g. ":8080"; empty disables the HTTP API
+	Mode              string                 `yaml:"mode,omitempty"`      // see Modes
+	Pressure          PressureConfig         `yaml:"pressure,omitempty"`
+	Speci             SpeciConfig            `yaml:"speci,omitempty"`
+	Degraded          DegradedConfig         `yaml:"degraded,omitempty"`
+	Transition        TransitionConfig       `yaml:"transition,omitempty"`
+	ColorCorrection   ColorCorrectionConfig  `yaml:"color_correction,omitempty"`
+	Hardware          HardwareConfig         `yaml:"hardware,omitempty"`
+	Backend           string                 `yaml:"backend,omitempty"` // see Backends
+	Simulator         SimulatorConfig        `yaml:"simulator,omitempty"`
+	Power             PowerConfig            `yaml:"power,omitempty"`
+	Theme             string                 `yaml:"theme,omitempty"` // see ThemePresets; switch at runtime with PUT /api/theme
+	Themes            map[string]ThemeConfig `yaml:"themes,omitempty"`
+	StaleAfterMin     int                    `yaml:"stale_after_min,omitempty"` // reports older than this show the stale color
+	Legend            LegendConfig           `yaml:"legend,omitempty"`
+	Status            StatusConfig           `yaml:"status,omitempty"`
+	Groups            map[string]GroupConfig `yaml:"groups,omitempty"`
+	CalibrationFile   string                 `yaml:"calibration_file,omitempty"` // relative to the config file; written by cmd/utils -tune
+	Calibration       CalibrationConfig      `yaml:"-"`                          // loaded from CalibrationFile
+	BrightnessControl BrightnessConfig       `yaml:"brightness_control,omitempty"`
+	QuietHours        QuietHoursConfig       `yaml:"quiet_hours,omitempty"`
 }
 
-func GetConfig(file *string) Config {
-	c := Config{}
+// QuietHoursConfig turns the display off during windows of the week, in Locale,
+// fading out at the start of each and back in at its end. Weather is still
+// fetched, so the map is current when it comes back on.
+type QuietHoursConfig struct {
+	FadeS   float64       `yaml:"fade_s,omitempty"` // length of the fades
+	Windows []QuietWindow `yaml:"windows,omitempty"`
+}
+
+// QuietWindow is quiet from Start to End on each of Days. An End at or before
+// Start runs past midnight into the next day.
+type QuietWindow struct {
+	Days  []string `yaml:"days,omitempty"` // e.g. mon or monday; empty means every day
+	Start string   `yaml:"start"`          // e.g. 22:00
+	End   string   `yaml:"end"`            // e.g. 07:00
+}
+
+// ParseWeekday reads a day name such as "mon" or "Monday".
+func ParseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(s)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// BrightnessConfig chooses what sets the brightness between night_brightness
+// and brightness. Each source reports a level from 0 (night) to 1 (day).
+type BrightnessConfig struct {
+	Sources    []BrightnessSourceConfig `yaml:"sources,omitempty"`     // defaults to the sun
+	Combine    string                   `yaml:"combine,omitempty"`     // min, max or average; see BrightnessCombines
+	SmoothingS float64                  `yaml:"smoothing_s,omitempty"` // time constant of the moving average
+	Hysteresis float64                  `yaml:"hysteresis,omitempty"`  // level changes smaller than this are ignored
+	Sun        SunConfig                `yaml:"sun,omitempty"`         // shapes the sun source
+}
+
+// SunConfig shapes the sun source's ramps between night and day. Keyframes add
+// levels at clock times, and the level eases between neighbouring points.
+type SunConfig struct {
+	Twilight  string          `yaml:"twilight,omitempty"`  // see Twilights; the ramps are centered where the sun crosses it
+	RampMin   float64         `yaml:"ramp_min,omitempty"`  // length of each ramp
+	Easing    string          `yaml:"easing,omitempty"`    // linear, ease_in, ease_out or ease_in_out
+	Keyframes []ScheduleEntry `yaml:"keyframes,omitempty"` // in Locale
+}
+
+// Twilights are the sun elevations, in degrees, the brightness ramps can be
+// centered on. "sunrise" is the horizon, allowing for refraction.
+var Twilights = map[string]float64{
+	"sunrise":      -0.833,
+	"civil":        -6,
+	"nautical":     -12,
+	"astronomical": -18,
+}
+
+// BrightnessSourceConfig is one input to the brightness level.
+type BrightnessSourceConfig struct {
+	Type      string          `yaml:"type"`                 // see BrightnessSourceTypes
+	Path      string          `yaml:"path,omitempty"`       // illuminance: sysfs file, e.g. /sys/bus/iio/devices/iio:device0/in_illuminance_input
+	Scale     float64         `yaml:"scale,omitempty"`      // illuminance: multiplies the file's value into lux
+	DarkLux   float64         `yaml:"dark_lux,omitempty"`   // illuminance: at or below, level 0
+	BrightLux float64         `yaml:"bright_lux,omitempty"` // illuminance: at or above, level 1
+	Schedule  []ScheduleEntry `yaml:"schedule,omitempty"`   // schedule: levels by time of day
+}
+
+// ScheduleEntry sets the level from a time of day, in Locale, until the next entry.
+type ScheduleEntry struct {
+	At    string  `yaml:"at"` // "15:04"
+	Level float64 `yaml:"level"`
+}
+
+// Brightness source types.
+const (
+	BrightnessSun         = "sun"
+	BrightnessIlluminance = "illuminance"
+	BrightnessSchedule    = "schedule"
+)
+
+var BrightnessSourceTypes = []string{BrightnessSun, BrightnessIlluminance, BrightnessSchedule}
+
+var BrightnessCombines = []string{"min", "max", "average"}
+
+// CalibrationConfig corrects LEDs that look dimmer or tinted than the rest, such
+// as those behind thicker paper. Group and LED calibrations combine.
+type CalibrationConfig struct {
+	Leds   map[int]LedCalibration    `yaml:"leds,omitempty"`
+	Groups map[string]LedCalibration `yaml:"groups,omitempty"` // by name from groups
+}
+
+// LedCalibration scales an LED's color, then adds an offset to each channel.
+type LedCalibration struct {
+	Brightness *float64 `yaml:"brightness,omitempty"` // multiplier, 0 or more; omitted means 1, 0 turns the LED off
+	Red        int      `yaml:"red,omitempty"`        // -255 to 255
+	Green      int      `yaml:"green,omitempty"`
+	Blue       int      `yaml:"blue,omitempty"`
+}
+
+// Multiplier returns the brightness multiplier, 1 when it is omitted.
+func (lc LedCalibration) Multiplier() float64 {
+	if lc.Brightness == nil {
+		return 1
+	}
+	return *lc.Brightness
+}
+
+// GroupConfig is a named set of LEDs that glow a fixed color, such as VORs, a
+// class B outline or a route line drawn on the chart.
+type GroupConfig struct {
+	Leds   []int        `yaml:"leds,omitempty"`
+	Ranges [][]int      `yaml:"ranges,omitempty"` // inclusive [first, last] pairs
+	Color  string       `yaml:"color"`
+	Effect EffectConfig `yaml:"effect,omitempty"` // optional overlay
+}
+
+// LedNumbers returns the group's LEDs, with ranges expanded, in the order given.
+func (g GroupConfig) LedNumbers() []int {
+	nums := slices.Clone(g.Leds)
+	for _, r := range g.Ranges {
+		if len(r) != 2 {
+			continue
+		}
+		for n := r[0]; n <= r[1]; n++ {
+			nums = append(nums, n)
+		}
+	}
+	return nums
+}
+
+// LegendPrefix marks entries in leds that show a theme color instead of a
+// station, e.g. "LEGEND:VFR" or "LEGEND:WINDY_IFR".
+const LegendPrefix = "LEGEND:"
+
+// LegendKeys are the states a legend LED can show.
+var LegendKeys = []string{
+	"VFR", "MVFR", "IFR", "LIFR",
+	"WINDY_VFR", "WINDY_MVFR", "WINDY_IFR", "WINDY_LIFR",
+	"UNKNOWN", "STALE",
+}
+
+// LegendKey returns the state shown by a legend entry in leds, and false for
+// station IDs.
+func LegendKey(id string) (string, bool) {
+	id = strings.ToUpper(id)
+	if !strings.HasPrefix(id, LegendPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(id, LegendPrefix), true
+}
+
+// StatusLed marks the entry in leds that shows system health, e.g. "48: STATUS".
+const StatusLed = "STATUS"
+
+// IsStation reports whether an entry in leds is a station ID rather than a
+// legend or status LED.
+func IsStation(id string) bool {
+	if _, ok := LegendKey(id); ok {
+		return false
+	}
+	return !strings.EqualFold(id, StatusLed)
+}
+
+// StatusConfig sets the pattern shown on the STATUS LED for each health state.
+type StatusConfig struct {
+	Healthy     EffectConfig `yaml:"healthy,omitempty"`      // a slow heartbeat
+	FetchFailed EffectConfig `yaml:"fetch_failed,omitempty"` // the API returned an error
+	NoNetwork   EffectConfig `yaml:"no_network,omitempty"`   // the API could not be reached, e.g. DNS failed
+	Stale       EffectConfig `yaml:"stale,omitempty"`        // no successful fetch within stale_after_min
+	Reloaded    EffectConfig `yaml:"reloaded,omitempty"`     // the config was reloaded with SIGHUP
+	ReloadedS   int          `yaml:"reloaded_s,omitempty"`   // seconds to show Reloaded
+	// RenderStalled is shown if the display loop recovers after missing its
+	// heartbeat; while it is stuck, nothing new reaches the LEDs.
+	RenderStalled EffectConfig `yaml:"render_stalled,omitempty"`
+}
+
+// LegendConfig controls the legend LEDs mapped in leds. The legend stays lit
+// through quiet hours unless DarkInQuietHours is set.
+type LegendConfig struct {
+	Brightness       *float64 `yaml:"brightness,omitempty"`          // 0-1, scales the theme colors; 1 if omitted
+	DisableAtNight   bool     `yaml:"disable_at_night,omitempty"`    // dark between sunset and sunrise
+	DarkInQuietHours bool     `yaml:"dark_in_quiet_hours,omitempty"` // fades out with the map in quiet_hours
+}
+
+// Level returns how far the theme colors are scaled, 1 when it is omitted.
+func (lc LegendConfig) Level() float64 {
+	if lc.Brightness == nil {
+		return 1
+	}
+	return *lc.Brightness
+}
+
+// ThemeConfig is a named set of hex colors for flight conditions. Colors left
+// empty are taken from the preset of the same name, or the default theme.
+type ThemeConfig struct {
+	VFR       string `yaml:"vfr,omitempty"`
+	MVFR      string `yaml:"mvfr,omitempty"`
+	IFR       string `yaml:"ifr,omitempty"`
+	LIFR      string `yaml:"lifr,omitempty"`
+	WindyVFR  string `yaml:"windy_vfr,omitempty"` // shown as wind rises from wind_low_kt to wind_high_kt
+	WindyMVFR string `yaml:"windy_mvfr,omitempty"`
+	WindyIFR  string `yaml:"windy_ifr,omitempty"`
+	WindyLIFR string `yaml:"windy_lifr,omitempty"`
+	Unknown   string `yaml:"unknown,omitempty"` // an unrecognised flight category
+	Stale     string `yaml:"stale,omitempty"`   // no flight category, or a report older than stale_after_min
+}
+
+// ThemePresets ship with twinkle. The colorblind presets are built from the
+// Okabe-Ito palette, keeping the categories apart by lightness as well as hue.
+var ThemePresets = map[string]ThemeConfig{
+	"default": {
+		VFR: "#32cd32", MVFR: "#0000ff", IFR: "#ff0000", LIFR: "#c71585",
+		WindyVFR: "#9acd32", WindyMVFR: "#4682b4", WindyIFR: "#ff4500", WindyLIFR: "#ff1493",
+		Unknown: "#faebd7", Stale: "#808080",
+	},
+	"deuteranopia": {
+		VFR: "#0072b2", MVFR: "#56b4e9", IFR: "#e69f00", LIFR: "#cc79a7",
+		WindyVFR: "#009e73", WindyMVFR: "#a6dcf5", WindyIFR: "#f0e442", WindyLIFR: "#e7b6d4",
+		Unknown: "#faebd7", Stale: "#808080",
+	},
+	"protanopia": {
+		VFR: "#56b4e9", MVFR: "#0000ff", IFR: "#f0e442", LIFR: "#e69f00",
+		WindyVFR: "#a6dcf5", WindyMVFR: "#0072b2", WindyIFR: "#fff8a0", WindyLIFR: "#ffcc66",
+		Unknown: "#faebd7", Stale: "#808080",
+	},
+}
+
+// PowerConfig models the strip's current draw so frames can be dimmed to stay
+// within what the power supply can deliver.
+type PowerConfig struct {
+	BudgetMA     float64 `yaml:"budget_ma,omitempty"`       // 0 disables limiting
+	MAPerChannel float64 `yaml:"ma_per_channel,omitempty"`  // draw of one color die at full value
+	IdleMAPerLed float64 `yaml:"idle_ma_per_led,omitempty"` // draw of each dark LED
+}
+
+// SimulatorConfig lays out the browser map served at /map by the HTTP API.
+type SimulatorConfig struct {
+	Background string               `yaml:"background,omitempty"` // image drawn behind the LEDs, e.g. a scanned sectional
+	Width      int                  `yaml:"width,omitempty"`      // pixels; defaults to the background size
+	Height     int                  `yaml:"height,omitempty"`
+	Bounds     BoundsConfig         `yaml:"bounds,omitempty"`    // lat/lon edges of the background
+	Positions  map[string][]float64 `yaml:"positions,omitempty"` // station ID to [x, y] pixels
+	HistoryS   int                  `yaml:"history_s,omitempty"` // seconds of LED states kept for animated GIFs
+}
+
+// BoundsConfig georeferences a map image by the coordinates of its edges.
+type BoundsConfig struct {
+	North float64 `yaml:"north"`
+	South float64 `yaml:"south"`
+	East  float64 `yaml:"east"`
+	West  float64 `yaml:"west"`
+}
+
+// Backends drive the LEDs.
+const (
+	BackendWS281x   = "ws281x"   // the strip on a Raspberry Pi
+	BackendTerminal = "terminal" // ANSI colors in the terminal, for running without a Pi
+	BackendHeadless = "headless" // drives nothing; the display loop still runs, e.g. for snapshots
+)
 
-	data, err := os.ReadFile(*file)
+var Backends = []string{BackendWS281x, BackendTerminal, BackendHeadless}
+
+// HardwareConfig holds the ws281x driver options.
+type HardwareConfig struct {
+	Frequency int             `yaml:"frequency,omitempty"` // Hz, 800000 or 400000
+	DMA       int             `yaml:"dma,omitempty"`
+	Channels  []ChannelConfig `yaml:"channels,omitempty"` // up to two
+	Segments  []SegmentConfig `yaml:"segments,omitempty"` // defaults to one segment per channel
+}
+
+// SegmentConfig maps a run of LEDs on one channel into the global LED numbering
+// used by leds. Segments are numbered consecutively in the order listed.
+type SegmentConfig struct {
+	Channel int  `yaml:"channel"`
+	Start   int  `yaml:"start,omitempty"` // first LED on the channel
+	Count   int  `yaml:"count"`
+	Reverse bool `yaml:"reverse,omitempty"` // number the segment from its last LED
+}
+
+// ChannelConfig holds the options for one ws281x output channel.
+type ChannelConfig struct {
+	GpioPin         int    `yaml:"gpio_pin,omitempty"`   // BCM numbering
+	StripType       string `yaml:"strip_type,omitempty"` // color order, e.g. rgb, grb or grbw
+	White           string `yaml:"white,omitempty"`      // RGBW white extraction: subtract, add or none
+	Invert          bool   `yaml:"invert,omitempty"`
+	LedCount        int    `yaml:"led_count,omitempty"`        // defaults to led_count on the first channel
+	Brightness      int    `yaml:"brightness,omitempty"`       // defaults to brightness
+	NightBrightness int    `yaml:"night_brightness,omitempty"` // defaults to night_brightness
+}
+
+// DefaultGamma matches the gamma8 table the ws281x driver applied before
+// ColorCorrection took over, so maps without a color_correction look the same.
+const DefaultGamma = 2.8
+
+// ColorCorrectionConfig sets the gamma curve and white balance applied to every
+// color before it is written to the strip. An omitted gamma defaults to
+// DefaultGamma and omitted scales to 1 (no change).
+type ColorCorrectionConfig struct {
+	Gamma float64 `yaml:"gamma,omitempty"`
+	Red   float64 `yaml:"red,omitempty"`
+	Green float64 `yaml:"green,omitempty"`
+	Blue  float64 `yaml:"blue,omitempty"`
+}
+
+// TransitionConfig controls the crossfade when a station changes color.
+type TransitionConfig struct {
+	Disabled   bool   `yaml:"disabled,omitempty"`
+	DurationMS int    `yaml:"duration_ms,omitempty"`
+	Easing     string `yaml:"easing,omitempty"` // linear, ease_in, ease_out or ease_in_out
+}
+
+// DegradedConfig controls the cue shown on stations reporting sensor problems.
+type DegradedConfig struct {
+	Disabled bool         `yaml:"disabled,omitempty"`
+	Effect   EffectConfig `yaml:"effect,omitempty"`
+}
+
+// SpeciConfig controls the attention effect shown on stations that issued a SPECI.
+type SpeciConfig struct {
+	Disabled     bool         `yaml:"disabled,omitempty"`
+	HighlightMin int          `yaml:"highlight_min,omitempty"` // minutes after the observation time
+	Effect       EffectConfig `yaml:"effect,omitempty"`
+}
+
+// Display modes select what the station colors represent.
+const (
+	ModeCategory         = "category"          // flight category, or go/no-go when a profile is selected
+	ModePressureTendency = "pressure_tendency" // three-hour pressure tendency
+	ModeAltimeter        = "altimeter"         // stations with unusually low altimeter settings
+)
+
+var Modes = []string{ModeCategory, ModePressureTendency, ModeAltimeter}
+
+// PressureConfig controls the pressure tendency and altimeter display modes.
+type PressureConfig struct {
+	MaxTendencyMb      float64 `yaml:"max_tendency_mb,omitempty"` // tendency shown at full intensity
+	FallingColor       string  `yaml:"falling_color,omitempty"`
+	RisingColor        string  `yaml:"rising_color,omitempty"`
+	SteadyColor        string  `yaml:"steady_color,omitempty"`
+	LowAltimeterInHg   float64 `yaml:"low_altimeter_in_hg,omitempty"`
+	AltimeterRangeInHg float64 `yaml:"altimeter_range_in_hg,omitempty"` // below the threshold, shown at full intensity
+	LowAltimeterColor  string  `yaml:"low_altimeter_color,omitempty"`
+}
+
+// Profile is a set of personal minimums. Zero limits are not checked.
+type Profile struct {
+	CeilingFt    float64 `yaml:"ceiling_ft,omitempty"`
+	VisibilitySM float64 `yaml:"visibility_sm,omitempty"`
+	WindKt       float64 `yaml:"wind_kt,omitempty"`
+	GustKt       float64 `yaml:"gust_kt,omitempty"`
+	CrosswindKt  float64 `yaml:"crosswind_kt,omitempty"`
+	GoColor      string  `yaml:"go_color,omitempty"`
+	NoGoColor    string  `yaml:"no_go_color,omitempty"`
+}
+
+// LightningConfig controls the flash effects shown for thunderstorms (TS) and
+// vicinity or distant lightning (VCTS, LTG DSNT).
+type LightningConfig struct {
+	Disabled             bool    `yaml:"disabled,omitempty"`
+	FlashesPerMin        float64 `yaml:"flashes_per_min,omitempty"`
+	Intensity            float64 `yaml:"intensity,omitempty"` // 0-1
+	DistantFlashesPerMin float64 `yaml:"distant_flashes_per_min,omitempty"`
+	DistantIntensity     float64 `yaml:"distant_intensity,omitempty"` // 0-1
+	DisableAtNight       bool    `yaml:"disable_at_night,omitempty"`
+}
+
+// PrecipConfig maps present-weather codes such as "SN" or "FZRA" to animated
+// overlays. When several are reported, the first match in Priority wins.
+type PrecipConfig struct {
+	Disabled bool                    `yaml:"disabled,omitempty"`
+	Priority []string                `yaml:"priority,omitempty"`
+	Effects  map[string]EffectConfig `yaml:"effects,omitempty"`
+}
+
+// Effects are the overlays an EffectConfig can name.
+var Effects = []string{"flash", "flicker", "twinkle", "pulse", "blink", "blip", "breathe", "sparkle", "fade", "solid"}
+
+// EffectConfig describes an animated overlay; Color is a hex string such as "#c8dcff".
+type EffectConfig struct {
+	Effect    string  `yaml:"effect"` // see Effects
+	Color     string  `yaml:"color"`
+	Rate      float64 `yaml:"rate,omitempty"`      // events or pulses per minute
+	Intensity float64 `yaml:"intensity,omitempty"` // 0-1
+}
+
+// ParseHexColor parses a config color such as "#c8dcff" or "#fff".
+// From https://stackoverflow.com/questions/54197913/parse-hex-string-to-image-color
+func ParseHexColor(s string) (c color.RGBA, err error) {
+	errInvalidFormat := fmt.Errorf("color %q should look like #rrggbb or #rgb", s)
+	c.A = 0xff
+
+	if len(s) == 0 || s[0] != '#' {
+		return c, errInvalidFormat
+	}
+
+	hexToByte := func(b byte) byte {
+		switch {
+		case b >= '0' && b <= '9':
+			return b - '0'
+		case b >= 'a' && b <= 'f':
+			return b - 'a' + 10
+		case b >= 'A' && b <= 'F':
+			return b - 'A' + 10
+		}
+		err = errInvalidFormat
+		return 0
+	}
+
+	switch len(s) {
+	case 7:
+		c.R = hexToByte(s[1])<<4 + hexToByte(s[2])
+		c.G = hexToByte(s[3])<<4 + hexToByte(s[4])
+		c.B = hexToByte(s[5])<<4 + hexToByte(s[6])
+	case 4:
+		c.R = hexToByte(s[1]) * 17
+		c.G = hexToByte(s[2]) * 17
+		c.B = hexToByte(s[3]) * 17
+	default:
+		err = errInvalidFormat
+	}
+	return
+}
+
+// GetConfig loads the configuration file, exiting if it cannot be used.
+func GetConfig(file *string) Config {
+	c, err := LoadConfig(*file)
 	if err != nil {
 		log.
 			Fatal().
 			Err(err).
 			Caller().
 			Str("configFile", *file).
-			Msg("Could not read config file")
+			Msg("Could not load configuration")
 	}
+	return c
+}
+
+// LoadConfig reads, defaults and validates a configuration file. Unlike
+// GetConfig it returns errors, so a bad edit can be rejected on reload.
+func LoadConfig(file string) (Config, error) {
+	c := Config{}
 
-	err = yaml.Unmarshal(data, &c)
+	data, err := os.ReadFile(file)
 	if err != nil {
-		log.
-			Fatal().
-			Err(err).
-			Caller().
-			Msg("Could not unmarshal configuration file")
+		return c, fmt.Errorf("could not read config file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("could not unmarshal configuration file: %w", err)
 	}
 
 	c.Stations = reverseLeds(c.Leds)
-	return c
+	setDefaults(&c)
+
+	if !filepath.IsAbs(c.CalibrationFile) {
+		c.CalibrationFile = filepath.Join(filepath.Dir(file), c.CalibrationFile)
+	}
+	if c.Calibration, err = LoadCalibration(c.CalibrationFile); err != nil {
+		return c, err
+	}
+
+	if !slices.Contains(Modes, c.Mode) {
+		return c, fmt.Errorf("unknown display mode %q, use one of %v", c.Mode, Modes)
+	}
+
+	if _, ok := c.Profiles[c.Profile]; c.Profile != "" && !ok {
+		return c, fmt.Errorf("selected profile %q not found in profiles", c.Profile)
+	}
+
+	if _, ok := c.Themes[c.Theme]; !ok {
+		return c, fmt.Errorf("selected theme %q not found in themes or presets", c.Theme)
+	}
+
+	for num, id := range c.Leds {
+		if key, ok := LegendKey(id); ok && !slices.Contains(LegendKeys, key) {
+			return c, fmt.Errorf("LED %d: unknown legend entry %q, use one of %v", num, id, LegendKeys)
+		}
+	}
+
+	if err := validateGroups(c); err != nil {
+		return c, err
+	}
+	if err := validateColors(c); err != nil {
+		return c, err
+	}
+	if l := c.Legend.Level(); l < 0 || l > 1 {
+		return c, fmt.Errorf("legend brightness %v should be between 0 and 1", l)
+	}
+	if err := validateCalibration(c); err != nil {
+		return c, err
+	}
+	if err := validatePressure(c.Pressure); err != nil {
+		return c, err
+	}
+	if err := validateBrightness(c.BrightnessControl); err != nil {
+		return c, err
+	}
+	if err := validateQuietHours(c.QuietHours); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// validateColors checks every hex color and effect, so a typo is rejected when
+// the config loads rather than drawn in a fallback color.
+func validateColors(c Config) error {
+	check := func(what, hex string) error {
+		if _, err := ParseHexColor(hex); err != nil {
+			return fmt.Errorf("%s: %w", what, err)
+		}
+		return nil
+	}
+	checkEffect := func(what string, ec EffectConfig) error {
+		if !slices.Contains(Effects, strings.ToLower(ec.Effect)) {
+			return fmt.Errorf("%s: unknown effect %q, use one of %v", what, ec.Effect, Effects)
+		}
+		return check(what, ec.Color)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Themes)) {
+		t := c.Themes[name]
+		for _, f := range []struct{ key, hex string }{
+			{"vfr", t.VFR}, {"mvfr", t.MVFR}, {"ifr", t.IFR}, {"lifr", t.LIFR},
+			{"windy_vfr", t.WindyVFR}, {"windy_mvfr", t.WindyMVFR}, {"windy_ifr", t.WindyIFR}, {"windy_lifr", t.WindyLIFR},
+			{"unknown", t.Unknown}, {"stale", t.Stale},
+		} {
+			if err := check(fmt.Sprintf("theme %q %s", name, f.key), f.hex); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Profiles)) {
+		p := c.Profiles[name]
+		if err := check(fmt.Sprintf("profile %q go_color", name), p.GoColor); err != nil {
+			return err
+		}
+		if err := check(fmt.Sprintf("profile %q no_go_color", name), p.NoGoColor); err != nil {
+			return err
+		}
+	}
+	for _, f := range []struct{ key, hex string }{
+		{"falling_color", c.Pressure.FallingColor},
+		{"rising_color", c.Pressure.RisingColor},
+		{"steady_color", c.Pressure.SteadyColor},
+		{"low_altimeter_color", c.Pressure.LowAltimeterColor},
+	} {
+		if err := check("pressure "+f.key, f.hex); err != nil {
+			return err
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Groups)) {
+		g := c.Groups[name]
+		if err := check(fmt.Sprintf("group %q color", name), g.Color); err != nil {
+			return err
+		}
+		if g.Effect.Effect != "" {
+			if err := checkEffect(fmt.Sprintf("group %q effect", name), g.Effect); err != nil {
+				return err
+			}
+		}
+	}
+
+	type namedEffect struct {
+		what string
+		ec   EffectConfig
+	}
+	effects := []namedEffect{
+		{"speci effect", c.Speci.Effect},
+		{"degraded effect", c.Degraded.Effect},
+		{"status healthy", c.Status.Healthy},
+		{"status fetch_failed", c.Status.FetchFailed},
+		{"status no_network", c.Status.NoNetwork},
+		{"status stale", c.Status.Stale},
+		{"status reloaded", c.Status.Reloaded},
+		{"status render_stalled", c.Status.RenderStalled},
+	}
+	for _, code := range slices.Sorted(maps.Keys(c.Precipitation.Effects)) {
+		effects = append(effects, namedEffect{"precipitation " + code, c.Precipitation.Effects[code]})
+	}
+	for _, e := range effects {
+		if err := checkEffect(e.what, e.ec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateQuietHours checks the quiet windows' days and times.
+func validateQuietHours(qc QuietHoursConfig) error {
+	for i, w := range qc.Windows {
+		for _, d := range w.Days {
+			if _, ok := ParseWeekday(d); !ok {
+				return fmt.Errorf("quiet window %d: unknown day %q, use e.g. mon or monday", i, d)
+			}
+		}
+		start, err := time.Parse("15:04", w.Start)
+		if err != nil {
+			return fmt.Errorf("quiet window %d: start %q should look like 22:00", i, w.Start)
+		}
+		end, err := time.Parse("15:04", w.End)
+		if err != nil {
+			return fmt.Errorf("quiet window %d: end %q should look like 07:00", i, w.End)
+		}
+		if start.Equal(end) {
+			return fmt.Errorf("quiet window %d: starts and ends at %s", i, w.Start)
+		}
+	}
+	return nil
+}
+
+// validatePressure checks the scales the pressure colors are blended over.
+func validatePressure(pc PressureConfig) error {
+	if pc.MaxTendencyMb <= 0 {
+		return fmt.Errorf("pressure max_tendency_mb %v should be more than 0", pc.MaxTendencyMb)
+	}
+	if pc.AltimeterRangeInHg <= 0 {
+		return fmt.Errorf("pressure altimeter_range_in_hg %v should be more than 0", pc.AltimeterRangeInHg)
+	}
+	return nil
+}
+
+// validateBrightness checks the brightness sources.
+func validateBrightness(bc BrightnessConfig) error {
+	if !slices.Contains(BrightnessCombines, bc.Combine) {
+		return fmt.Errorf("unknown brightness combine %q, use one of %v", bc.Combine, BrightnessCombines)
+	}
+	if _, ok := Twilights[bc.Sun.Twilight]; !ok {
+		return fmt.Errorf("unknown twilight %q, use one of %v", bc.Sun.Twilight, slices.Sorted(maps.Keys(Twilights)))
+	}
+	for _, e := range bc.Sun.Keyframes {
+		if _, err := time.Parse("15:04", e.At); err != nil {
+			return fmt.Errorf("sun keyframe time %q should look like 07:30", e.At)
+		}
+	}
+	for i, src := range bc.Sources {
+		switch src.Type {
+		case BrightnessSun:
+		case BrightnessIlluminance:
+			if src.Path == "" {
+				return fmt.Errorf("brightness source %d: illuminance needs a path", i)
+			}
+			// The level is on a log scale from dark_lux, so it must be above 0.
+			if src.DarkLux <= 0 {
+				return fmt.Errorf("brightness source %d: dark_lux %v should be more than 0", i, src.DarkLux)
+			}
+			if src.DarkLux >= src.BrightLux {
+				return fmt.Errorf("brightness source %d: dark_lux %v should be below bright_lux %v", i, src.DarkLux, src.BrightLux)
+			}
+		case BrightnessSchedule:
+			if len(src.Schedule) == 0 {
+				return fmt.Errorf("brightness source %d: schedule has no entries", i)
+			}
+			for _, e := range src.Schedule {
+				if _, err := time.Parse("15:04", e.At); err != nil {
+					return fmt.Errorf("brightness source %d: schedule time %q should look like 07:30", i, e.At)
+				}
+			}
+		default:
+			return fmt.Errorf("brightness source %d: unknown type %q, use one of %v", i, src.Type, BrightnessSourceTypes)
+		}
+	}
+	return nil
+}
+
+// LoadCalibration reads a calibration file; a missing file means no calibration.
+func LoadCalibration(file string) (CalibrationConfig, error) {
+	var cal CalibrationConfig
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cal, nil
+	}
+	if err != nil {
+		return cal, fmt.Errorf("could not read calibration file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &cal); err != nil {
+		return cal, fmt.Errorf("could not unmarshal calibration file %s: %w", file, err)
+	}
+	return cal, nil
+}
+
+// SaveCalibration replaces the calibration file.
+func SaveCalibration(file string, cal CalibrationConfig) error {
+	data, err := yaml.Marshal(cal)
+	if err != nil {
+		return err
+	}
+	header := "# Written by go run ./cmd/utils -tune\n"
+	return os.WriteFile(file, append([]byte(header), data...), 0o644)
+}
+
+// validateCalibration checks that calibrated LEDs and groups exist.
+func validateCalibration(c Config) error {
+	check := func(what string, lc LedCalibration) error {
+		if lc.Multiplier() < 0 {
+			return fmt.Errorf("calibration for %s: brightness %v is negative", what, lc.Multiplier())
+		}
+		for _, v := range []int{lc.Red, lc.Green, lc.Blue} {
+			if v < -255 || v > 255 {
+				return fmt.Errorf("calibration for %s: offset %d should be between -255 and 255", what, v)
+			}
+		}
+		return nil
+	}
+	for num, lc := range c.Calibration.Leds {
+		if num < 0 || num >= c.LedCount {
+			return fmt.Errorf("calibration for LED %d: outside the strip of %d", num, c.LedCount)
+		}
+		if err := check(fmt.Sprintf("LED %d", num), lc); err != nil {
+			return err
+		}
+	}
+	for name, lc := range c.Calibration.Groups {
+		if _, ok := c.Groups[name]; !ok {
+			return fmt.Errorf("calibration for group %q: not found in groups", name)
+		}
+		if err := check(fmt.Sprintf("group %q", name), lc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateGroups checks that group LEDs are on the strip and not shared with
+// leds or another group.
+func validateGroups(c Config) error {
+	owners := make(map[int]string)
+	for _, name := range slices.Sorted(maps.Keys(c.Groups)) {
+		g := c.Groups[name]
+		for _, r := range g.Ranges {
+			if len(r) != 2 || r[0] > r[1] {
+				return fmt.Errorf("group %q: range %v should be [first, last]", name, r)
+			}
+		}
+		for _, n := range g.LedNumbers() {
+			if n < 0 || n >= c.LedCount {
+				return fmt.Errorf("group %q: LED %d is outside the strip of %d", name, n, c.LedCount)
+			}
+			if id, ok := c.Leds[n]; ok {
+				return fmt.Errorf("group %q: LED %d is already mapped to %s", name, n, id)
+			}
+			if other, ok := owners[n]; ok {
+				return fmt.Errorf("group %q: LED %d is already in group %q", name, n, other)
+			}
+			owners[n] = name
+		}
+	}
+	return nil
+}
+
+// setDefaults fills in values for optional settings omitted from the YAML.
+func setDefaults(c *Config) {
+	if c.AnimationTickMS == 0 {
+		c.AnimationTickMS = c.LedRefreshRateMS
+	}
+	if c.AnimationTickMS == 0 {
+		c.AnimationTickMS = 40
+	}
+	if c.Lightning.FlashesPerMin == 0 {
+		c.Lightning.FlashesPerMin = 12
+	}
+	if c.Lightning.Intensity == 0 {
+		c.Lightning.Intensity = 1
+	}
+	if c.Lightning.DistantFlashesPerMin == 0 {
+		c.Lightning.DistantFlashesPerMin = 6
+	}
+	if c.Lightning.DistantIntensity == 0 {
+		c.Lightning.DistantIntensity = 0.35
+	}
+	if len(c.Precipitation.Priority) == 0 {
+		c.Precipitation.Priority = []string{"FZRA", "FZDZ", "PL", "GR", "SN", "RA"}
+	}
+	if c.Precipitation.Effects == nil {
+		c.Precipitation.Effects = map[string]EffectConfig{
+			"FZRA": {Effect: "pulse", Color: "#ff33cc", Rate: 30, Intensity: 0.6},
+			"FZDZ": {Effect: "pulse", Color: "#ff33cc", Rate: 20, Intensity: 0.4},
+			"PL":   {Effect: "twinkle", Color: "#ff99ee", Rate: 40, Intensity: 0.5},
+			"GR":   {Effect: "flicker", Color: "#ffffff", Rate: 40, Intensity: 0.6},
+			"SN":   {Effect: "twinkle", Color: "#c8dcff", Rate: 40, Intensity: 0.5},
+			"RA":   {Effect: "pulse", Color: "#3366ff", Rate: 10, Intensity: 0.25},
+		}
+	}
+	effects := make(map[string]EffectConfig, len(c.Precipitation.Effects))
+	for code, e := range c.Precipitation.Effects {
+		effects[strings.ToUpper(code)] = e
+	}
+	c.Precipitation.Effects = effects
+	for i, code := range c.Precipitation.Priority {
+		c.Precipitation.Priority[i] = strings.ToUpper(code)
+	}
+
+	for name, p := range c.Profiles {
+		if p.GoColor == "" {
+			p.GoColor = "#32cd32"
+		}
+		if p.NoGoColor == "" {
+			p.NoGoColor = "#ff0000"
+		}
+		c.Profiles[name] = p
+	}
+
+	if c.Backend == "" {
+		c.Backend = BackendWS281x
+	}
+
+	if c.Mode == "" {
+		c.Mode = ModeCategory
+	}
+	if c.Pressure.MaxTendencyMb == 0 {
+		c.Pressure.MaxTendencyMb = 3
+	}
+	if c.Pressure.FallingColor == "" {
+		c.Pressure.FallingColor = "#ff4500"
+	}
+	if c.Pressure.RisingColor == "" {
+		c.Pressure.RisingColor = "#1e90ff"
+	}
+	if c.Pressure.SteadyColor == "" {
+		c.Pressure.SteadyColor = "#303030"
+	}
+	if c.Pressure.LowAltimeterInHg == 0 {
+		c.Pressure.LowAltimeterInHg = 29.70
+	}
+	if c.Pressure.AltimeterRangeInHg == 0 {
+		c.Pressure.AltimeterRangeInHg = 0.5
+	}
+	if c.Pressure.LowAltimeterColor == "" {
+		c.Pressure.LowAltimeterColor = "#ff00ff"
+	}
+
+	if c.Speci.HighlightMin == 0 {
+		c.Speci.HighlightMin = 15
+	}
+	if c.Speci.Effect.Effect == "" {
+		c.Speci.Effect = EffectConfig{Effect: "blink", Color: "#ffffff", Rate: 6, Intensity: 0.7}
+	}
+
+	if c.Degraded.Effect.Effect == "" {
+		c.Degraded.Effect = EffectConfig{Effect: "blip", Color: "#000000", Rate: 4, Intensity: 0.7}
+	}
+
+	if c.Transition.DurationMS == 0 {
+		c.Transition.DurationMS = 1500
+	}
+	if c.Transition.Easing == "" {
+		c.Transition.Easing = "ease_in_out"
+	}
+
+	if c.ColorCorrection.Gamma == 0 {
+		c.ColorCorrection.Gamma = DefaultGamma
+	}
+	for _, v := range []*float64{
+		&c.ColorCorrection.Red,
+		&c.ColorCorrection.Green,
+		&c.ColorCorrection.Blue,
+	} {
+		if *v == 0 {
+			*v = 1
+		}
+	}
+
+	if c.Hardware.Frequency == 0 {
+		c.Hardware.Frequency = 800000
+	}
+	if c.Hardware.DMA == 0 {
+		c.Hardware.DMA = 10
+	}
+	if len(c.Hardware.Channels) == 0 {
+		c.Hardware.Channels = []ChannelConfig{{GpioPin: 12}}
+	}
+	if c.Hardware.Channels[0].LedCount == 0 {
+		c.Hardware.Channels[0].LedCount = c.LedCount
+	}
+	for i := range c.Hardware.Channels {
+		ch := &c.Hardware.Channels[i]
+		if ch.StripType == "" {
+			ch.StripType = "rgb"
+		}
+		ch.StripType = strings.ToLower(ch.StripType)
+		if ch.White == "" {
+			ch.White = "subtract"
+		}
+		if ch.Brightness == 0 {
+			ch.Brightness = c.Brightness
+		}
+		if ch.NightBrightness == 0 {
+			ch.NightBrightness = c.NightBrightness
+		}
+	}
+	if len(c.Hardware.Segments) == 0 {
+		for i, ch := range c.Hardware.Channels {
+			c.Hardware.Segments = append(c.Hardware.Segments, SegmentConfig{Channel: i, Count: ch.LedCount})
+		}
+	}
+	c.LedCount = 0
+	for _, seg := range c.Hardware.Segments {
+		c.LedCount += seg.Count
+	}
+
+	runways := make(map[string][]float64, len(c.Runways))
+	for station, headings := range c.Runways {
+		runways[strings.ToUpper(station)] = headings
+	}
+	c.Runways = runways
+
+	if c.Theme == "" {
+		c.Theme = "default"
+	}
+	themes := maps.Clone(ThemePresets)
+	for name, t := range c.Themes {
+		base, ok := ThemePresets[name]
+		if !ok {
+			base = ThemePresets["default"]
+		}
+		themes[name] = mergeTheme(t, base)
+	}
+	c.Themes = themes
+	if c.StaleAfterMin == 0 {
+		c.StaleAfterMin = 120
+	}
+	if len(c.BrightnessControl.Sources) == 0 {
+		c.BrightnessControl.Sources = []BrightnessSourceConfig{{Type: BrightnessSun}}
+	}
+	for i := range c.BrightnessControl.Sources {
+		src := &c.BrightnessControl.Sources[i]
+		src.Type = strings.ToLower(src.Type)
+		if src.Scale == 0 {
+			src.Scale = 1
+		}
+		if src.DarkLux == 0 {
+			src.DarkLux = 1
+		}
+		if src.BrightLux == 0 {
+			src.BrightLux = 200
+		}
+	}
+	if c.QuietHours.FadeS == 0 {
+		c.QuietHours.FadeS = 60
+	}
+	if c.BrightnessControl.Sun.Twilight == "" {
+		c.BrightnessControl.Sun.Twilight = "sunrise"
+	}
+	c.BrightnessControl.Sun.Twilight = strings.ToLower(c.BrightnessControl.Sun.Twilight)
+	if c.BrightnessControl.Sun.RampMin == 0 {
+		c.BrightnessControl.Sun.RampMin = 60
+	}
+	if c.BrightnessControl.Sun.Easing == "" {
+		c.BrightnessControl.Sun.Easing = "linear"
+	}
+	if c.BrightnessControl.Combine == "" {
+		c.BrightnessControl.Combine = "min"
+	}
+	if c.BrightnessControl.SmoothingS == 0 {
+		c.BrightnessControl.SmoothingS = 20
+	}
+	if c.BrightnessControl.Hysteresis == 0 {
+		c.BrightnessControl.Hysteresis = 0.02
+	}
+
+	if c.CalibrationFile == "" {
+		c.CalibrationFile = "calibration.yaml"
+	}
+
+	for _, s := range []struct {
+		effect *EffectConfig
+		def    EffectConfig
+	}{
+		{&c.Status.Healthy, EffectConfig{Effect: "breathe", Color: "#00ff00", Rate: 10, Intensity: 0.6}},
+		{&c.Status.FetchFailed, EffectConfig{Effect: "blink", Color: "#ff8c00", Rate: 30, Intensity: 1}},
+		{&c.Status.NoNetwork, EffectConfig{Effect: "blink", Color: "#ff0000", Rate: 60, Intensity: 1}},
+		{&c.Status.Stale, EffectConfig{Effect: "pulse", Color: "#ffff00", Rate: 20, Intensity: 1}},
+		{&c.Status.Reloaded, EffectConfig{Effect: "blink", Color: "#ffffff", Rate: 120, Intensity: 1}},
+		{&c.Status.RenderStalled, EffectConfig{Effect: "flicker", Color: "#ff00ff", Rate: 60, Intensity: 1}},
+	} {
+		if s.effect.Effect == "" {
+			*s.effect = s.def
+		}
+	}
+	if c.Status.ReloadedS == 0 {
+		c.Status.ReloadedS = 15
+	}
+
+	if c.Power.MAPerChannel == 0 {
+		c.Power.MAPerChannel = 20
+	}
+	if c.Power.IdleMAPerLed == 0 {
+		c.Power.IdleMAPerLed = 1
+	}
+
+	if c.Simulator.HistoryS == 0 {
+		c.Simulator.HistoryS = 60
+	}
+	positions := make(map[string][]float64, len(c.Simulator.Positions))
+	for station, xy := range c.Simulator.Positions {
+		positions[strings.ToUpper(station)] = xy
+	}
+	c.Simulator.Positions = positions
+}
+
+// mergeTheme fills the colors missing from t with those of base.
+func mergeTheme(t, base ThemeConfig) ThemeConfig {
+	fill := func(v *string, def string) {
+		if *v == "" {
+			*v = def
+		}
+	}
+	fill(&t.VFR, base.VFR)
+	fill(&t.MVFR, base.MVFR)
+	fill(&t.IFR, base.IFR)
+	fill(&t.LIFR, base.LIFR)
+	fill(&t.WindyVFR, base.WindyVFR)
+	fill(&t.WindyMVFR, base.WindyMVFR)
+	fill(&t.WindyIFR, base.WindyIFR)
+	fill(&t.WindyLIFR, base.WindyLIFR)
+	fill(&t.Unknown, base.Unknown)
+	fill(&t.Stale, base.Stale)
+	return t
 }
 
 func reverseLeds(m map[int]string) map[string]int {
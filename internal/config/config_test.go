@@ -2,7 +2,12 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"slices"
 	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
 )
 
 func TestGetConfig(t *testing.T) {
@@ -90,3 +95,571 @@ func TestReverseLeds_Empty(t *testing.T) {
 		t.Errorf("expected empty map, got %d entries", len(result))
 	}
 }
+
+func TestSetDefaults(t *testing.T) {
+	c := Config{Lightning: LightningConfig{Intensity: 0.5}}
+	setDefaults(&c)
+
+	if c.Lightning.FlashesPerMin != 12 {
+		t.Errorf("FlashesPerMin: got %v, want 12", c.Lightning.FlashesPerMin)
+	}
+	if c.Lightning.Intensity != 0.5 {
+		t.Errorf("Intensity: got %v, want 0.5 (explicit value kept)", c.Lightning.Intensity)
+	}
+	if c.Lightning.DistantIntensity != 0.35 {
+		t.Errorf("DistantIntensity: got %v, want 0.35", c.Lightning.DistantIntensity)
+	}
+}
+
+func TestSetDefaults_PrecipitationCodesUppercased(t *testing.T) {
+	c := Config{Precipitation: PrecipConfig{
+		Priority: []string{"sn", "ra"},
+		Effects:  map[string]EffectConfig{"sn": {Effect: "twinkle", Color: "#fff"}},
+	}}
+	setDefaults(&c)
+
+	if c.Precipitation.Priority[0] != "SN" || c.Precipitation.Priority[1] != "RA" {
+		t.Errorf("Priority: got %v, want [SN RA]", c.Precipitation.Priority)
+	}
+	if _, ok := c.Precipitation.Effects["SN"]; !ok {
+		t.Errorf("Effects: got %v, want key SN", c.Precipitation.Effects)
+	}
+	if len(c.Precipitation.Effects) != 1 {
+		t.Errorf("Effects: got %d entries, want 1 (explicit mapping not merged with defaults)", len(c.Precipitation.Effects))
+	}
+}
+
+func TestSetDefaults_Profiles(t *testing.T) {
+	c := Config{
+		Profiles: map[string]Profile{"student_solo": {CeilingFt: 3000, NoGoColor: "#ff8800"}},
+		Runways:  map[string][]float64{"koak": {100, 280}},
+	}
+	setDefaults(&c)
+
+	p := c.Profiles["student_solo"]
+	if p.GoColor != "#32cd32" {
+		t.Errorf("GoColor: got %q, want default #32cd32", p.GoColor)
+	}
+	if p.NoGoColor != "#ff8800" {
+		t.Errorf("NoGoColor: got %q, want #ff8800 (explicit value kept)", p.NoGoColor)
+	}
+	if len(c.Runways["KOAK"]) != 2 {
+		t.Errorf("Runways: got %v, want KOAK uppercased", c.Runways)
+	}
+}
+
+func TestSetDefaults_ColorCorrection(t *testing.T) {
+	c := Config{ColorCorrection: ColorCorrectionConfig{Gamma: 2.2, Blue: 0.8}}
+	setDefaults(&c)
+
+	cc := c.ColorCorrection
+	if cc.Gamma != 2.2 || cc.Red != 1 || cc.Green != 1 || cc.Blue != 0.8 {
+		t.Errorf("got %+v, want gamma 2.2, blue 0.8 and the rest 1", cc)
+	}
+
+	c = Config{}
+	setDefaults(&c)
+	if c.ColorCorrection.Gamma != DefaultGamma {
+		t.Errorf("omitted gamma: got %v, want %v to match the driver's old curve", c.ColorCorrection.Gamma, DefaultGamma)
+	}
+}
+
+func TestSetDefaults_Hardware(t *testing.T) {
+	c := Config{LedCount: 50, Brightness: 100, NightBrightness: 10}
+	setDefaults(&c)
+
+	hw := c.Hardware
+	if hw.Frequency != 800000 || hw.DMA != 10 {
+		t.Errorf("got frequency %d dma %d, want 800000 and 10", hw.Frequency, hw.DMA)
+	}
+	if len(hw.Channels) != 1 || hw.Channels[0] != (ChannelConfig{GpioPin: 12, StripType: "rgb", White: "subtract", LedCount: 50, Brightness: 100, NightBrightness: 10}) {
+		t.Errorf("Channels: got %+v, want pin 12 rgb with 50 LEDs", hw.Channels)
+	}
+
+	c = Config{LedCount: 50, Hardware: HardwareConfig{Channels: []ChannelConfig{{GpioPin: 18, StripType: "GRB"}}}}
+	setDefaults(&c)
+	if ch := c.Hardware.Channels[0]; ch.GpioPin != 18 || ch.StripType != "grb" || ch.LedCount != 50 {
+		t.Errorf("got %+v, want pin 18 grb with 50 LEDs", ch)
+	}
+}
+
+func TestSetDefaults_Segments(t *testing.T) {
+	c := Config{LedCount: 50, Brightness: 100, Hardware: HardwareConfig{Channels: []ChannelConfig{
+		{GpioPin: 12},
+		{GpioPin: 13, LedCount: 30, Brightness: 60},
+	}}}
+	setDefaults(&c)
+
+	want := []SegmentConfig{{Channel: 0, Count: 50}, {Channel: 1, Count: 30}}
+	if !slices.Equal(c.Hardware.Segments, want) {
+		t.Errorf("Segments: got %+v, want %+v", c.Hardware.Segments, want)
+	}
+	if c.LedCount != 80 {
+		t.Errorf("LedCount: got %d, want 80 across both channels", c.LedCount)
+	}
+	if b := c.Hardware.Channels[1].Brightness; b != 60 {
+		t.Errorf("channel 1 brightness: got %d, want 60", b)
+	}
+
+	c = Config{LedCount: 50, Hardware: HardwareConfig{Segments: []SegmentConfig{
+		{Channel: 0, Count: 20}, {Channel: 0, Start: 30, Count: 20, Reverse: true},
+	}}}
+	setDefaults(&c)
+	if c.LedCount != 40 {
+		t.Errorf("LedCount: got %d, want 40 from explicit segments", c.LedCount)
+	}
+}
+
+func TestSetDefaults_Simulator(t *testing.T) {
+	c := Config{Simulator: SimulatorConfig{Positions: map[string][]float64{"ksfo": {120, 340}}}}
+	setDefaults(&c)
+	if xy := c.Simulator.Positions["KSFO"]; len(xy) != 2 {
+		t.Errorf("Positions: got %v, want KSFO uppercased", c.Simulator.Positions)
+	}
+	if c.Simulator.HistoryS != 60 {
+		t.Errorf("HistoryS: got %d, want 60", c.Simulator.HistoryS)
+	}
+}
+
+func TestSetDefaults_Power(t *testing.T) {
+	c := Config{Power: PowerConfig{BudgetMA: 3500}}
+	setDefaults(&c)
+	if p := c.Power; p.BudgetMA != 3500 || p.MAPerChannel != 20 || p.IdleMAPerLed != 1 {
+		t.Errorf("got %+v, want budget kept, 20mA per channel and 1mA idle", p)
+	}
+}
+
+func TestSetDefaults_Themes(t *testing.T) {
+	c := Config{Themes: map[string]ThemeConfig{
+		"mine":       {VFR: "#00ff00"},
+		"protanopia": {Stale: "#202020"},
+	}}
+	setDefaults(&c)
+
+	if c.Theme != "default" {
+		t.Errorf("Theme: got %q, want default", c.Theme)
+	}
+	for name := range ThemePresets {
+		if _, ok := c.Themes[name]; !ok {
+			t.Errorf("preset %q missing from Themes", name)
+		}
+	}
+	if mine := c.Themes["mine"]; mine.VFR != "#00ff00" || mine.MVFR != ThemePresets["default"].MVFR {
+		t.Errorf("mine: got %+v, want VFR kept and the rest from default", mine)
+	}
+	if p := c.Themes["protanopia"]; p.Stale != "#202020" || p.VFR != ThemePresets["protanopia"].VFR {
+		t.Errorf("protanopia: got %+v, want Stale overridden and the rest from the preset", p)
+	}
+	if c.StaleAfterMin != 120 {
+		t.Errorf("StaleAfterMin: got %d, want 120", c.StaleAfterMin)
+	}
+}
+
+func TestLegendKey(t *testing.T) {
+	tests := []struct {
+		id     string
+		key    string
+		legend bool
+	}{
+		{"LEGEND:VFR", "VFR", true},
+		{"legend:windy_ifr", "WINDY_IFR", true},
+		{"KSFO", "", false},
+	}
+	for _, tt := range tests {
+		key, ok := LegendKey(tt.id)
+		if key != tt.key || ok != tt.legend {
+			t.Errorf("LegendKey(%q) = %q, %v; want %q, %v", tt.id, key, ok, tt.key, tt.legend)
+		}
+	}
+}
+
+func TestLegendConfig_Level(t *testing.T) {
+	c := Config{}
+	setDefaults(&c)
+	if got := c.Legend.Level(); got != 1 {
+		t.Errorf("omitted: got %v, want 1", got)
+	}
+	var lc LegendConfig
+	if err := yaml.Unmarshal([]byte("brightness: 0\n"), &lc); err != nil {
+		t.Fatal(err)
+	}
+	if got := lc.Level(); got != 0 {
+		t.Errorf("brightness 0: got %v, want 0 (dark)", got)
+	}
+}
+
+func TestIsStation(t *testing.T) {
+	for id, want := range map[string]bool{
+		"KSFO":       true,
+		"LEGEND:VFR": false,
+		"status":     false,
+	} {
+		if got := IsStation(id); got != want {
+			t.Errorf("IsStation(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestSetDefaults_Status(t *testing.T) {
+	c := Config{Status: StatusConfig{Stale: EffectConfig{Effect: "blip", Color: "#0000ff"}}}
+	setDefaults(&c)
+	if c.Status.Stale.Effect != "blip" {
+		t.Errorf("Stale: got %+v, want the configured effect kept", c.Status.Stale)
+	}
+	for name, e := range map[string]EffectConfig{
+		"healthy":        c.Status.Healthy,
+		"fetch_failed":   c.Status.FetchFailed,
+		"no_network":     c.Status.NoNetwork,
+		"reloaded":       c.Status.Reloaded,
+		"render_stalled": c.Status.RenderStalled,
+	} {
+		if e.Effect == "" || e.Color == "" {
+			t.Errorf("%s: got %+v, want a default effect", name, e)
+		}
+	}
+	if c.Status.ReloadedS != 15 {
+		t.Errorf("ReloadedS: got %d, want 15", c.Status.ReloadedS)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	for name, yaml := range map[string]string{
+		"mode":              "mode: radar\n",
+		"theme":             "theme: neon\n",
+		"legend":            "leds:\n  0: LEGEND:SPECI\n",
+		"legend brightness": "legend:\n  brightness: 1.5\n",
+		"yaml":              "leds: [\n",
+	} {
+		f, err := os.CreateTemp("", "config-*.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+		f.WriteString(yaml)
+		f.Close()
+
+		if _, err := LoadConfig(f.Name()); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := LoadConfig("does-not-exist.yaml"); err == nil {
+		t.Error("missing file: expected an error")
+	}
+}
+
+func TestGroupLedNumbers(t *testing.T) {
+	g := GroupConfig{Leds: []int{7, 3}, Ranges: [][]int{{10, 12}}}
+	if got, want := g.LedNumbers(), []int{7, 3, 10, 11, 12}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestValidateGroups(t *testing.T) {
+	base := Config{LedCount: 20, Leds: map[int]string{0: "KSFO"}}
+	tests := []struct {
+		name   string
+		groups map[string]GroupConfig
+		ok     bool
+	}{
+		{"valid", map[string]GroupConfig{"vors": {Leds: []int{1, 2}}, "route": {Ranges: [][]int{{5, 9}}}}, true},
+		{"outside strip", map[string]GroupConfig{"vors": {Leds: []int{20}}}, false},
+		{"station", map[string]GroupConfig{"vors": {Leds: []int{0}}}, false},
+		{"overlap", map[string]GroupConfig{"vors": {Leds: []int{6}}, "route": {Ranges: [][]int{{5, 9}}}}, false},
+		{"bad range", map[string]GroupConfig{"route": {Ranges: [][]int{{9, 5}}}}, false},
+	}
+	for _, tt := range tests {
+		c := base
+		c.Groups = tt.groups
+		if err := validateGroups(c); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func multiplier(v float64) *float64 { return &v }
+
+func TestCalibrationRoundTrip(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "calibration.yaml")
+
+	cal, err := LoadCalibration(file)
+	if err != nil || len(cal.Leds) != 0 {
+		t.Fatalf("missing file: got %+v, %v; want no calibration", cal, err)
+	}
+
+	want := CalibrationConfig{
+		Leds:   map[int]LedCalibration{12: {Brightness: multiplier(1.4), Red: -8}, 13: {Brightness: multiplier(0)}},
+		Groups: map[string]LedCalibration{"vors": {Brightness: multiplier(0.7)}},
+	}
+	if err := SaveCalibration(file, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadCalibration(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	same := func(a, b LedCalibration) bool {
+		return (a.Brightness == nil) == (b.Brightness == nil) && a.Multiplier() == b.Multiplier() &&
+			a.Red == b.Red && a.Green == b.Green && a.Blue == b.Blue
+	}
+	for _, num := range []int{12, 13} {
+		if !same(got.Leds[num], want.Leds[num]) {
+			t.Errorf("LED %d: got %+v, want %+v", num, got.Leds[num], want.Leds[num])
+		}
+	}
+	if !same(got.Groups["vors"], want.Groups["vors"]) {
+		t.Errorf("group: got %+v, want %+v", got.Groups["vors"], want.Groups["vors"])
+	}
+}
+
+func TestLoadConfig_CalibrationFile(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.yaml")
+	os.WriteFile(config, []byte("led_count: 10\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "calibration.yaml"), []byte("leds:\n  3: {brightness: 1.5}\n"), 0o644)
+
+	c, err := LoadConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.CalibrationFile != filepath.Join(dir, "calibration.yaml") {
+		t.Errorf("CalibrationFile: got %q, want it next to the config", c.CalibrationFile)
+	}
+	if c.Calibration.Leds[3].Multiplier() != 1.5 {
+		t.Errorf("Calibration: got %+v", c.Calibration)
+	}
+}
+
+func TestValidateCalibration(t *testing.T) {
+	base := Config{LedCount: 10, Groups: map[string]GroupConfig{"vors": {Leds: []int{1}}}}
+	tests := []struct {
+		name string
+		cal  CalibrationConfig
+		ok   bool
+	}{
+		{"valid", CalibrationConfig{Leds: map[int]LedCalibration{2: {Red: 20}}, Groups: map[string]LedCalibration{"vors": {Brightness: multiplier(2)}}}, true},
+		{"off", CalibrationConfig{Leds: map[int]LedCalibration{2: {Brightness: multiplier(0)}}}, true},
+		{"outside strip", CalibrationConfig{Leds: map[int]LedCalibration{10: {}}}, false},
+		{"unknown group", CalibrationConfig{Groups: map[string]LedCalibration{"route": {}}}, false},
+		{"offset", CalibrationConfig{Leds: map[int]LedCalibration{2: {Blue: 300}}}, false},
+		{"brightness", CalibrationConfig{Leds: map[int]LedCalibration{2: {Brightness: multiplier(-1)}}}, false},
+	}
+	for _, tt := range tests {
+		c := base
+		c.Calibration = tt.cal
+		if err := validateCalibration(c); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestSetDefaults_BrightnessControl(t *testing.T) {
+	c := Config{BrightnessControl: BrightnessConfig{Sources: []BrightnessSourceConfig{{Type: "Illuminance", Path: "/dev/null"}}}}
+	setDefaults(&c)
+	bc := c.BrightnessControl
+	if src := bc.Sources[0]; src.Type != BrightnessIlluminance || src.Scale != 1 || src.DarkLux != 1 || src.BrightLux != 200 {
+		t.Errorf("source: got %+v", src)
+	}
+	if bc.Combine != "min" || bc.SmoothingS != 20 || bc.Hysteresis != 0.02 {
+		t.Errorf("got %+v", bc)
+	}
+	if bc.Sun.Twilight != "sunrise" || bc.Sun.RampMin != 60 || bc.Sun.Easing != "linear" {
+		t.Errorf("sun: got %+v", bc.Sun)
+	}
+
+	c = Config{}
+	setDefaults(&c)
+	if s := c.BrightnessControl.Sources; len(s) != 1 || s[0].Type != BrightnessSun {
+		t.Errorf("default sources: got %+v, want the sun", s)
+	}
+}
+
+func TestValidateBrightness(t *testing.T) {
+	tests := []struct {
+		name string
+		src  BrightnessSourceConfig
+		ok   bool
+	}{
+		{"sun", BrightnessSourceConfig{Type: BrightnessSun}, true},
+		{"illuminance", BrightnessSourceConfig{Type: BrightnessIlluminance, Path: "/sys/x", DarkLux: 1, BrightLux: 200}, true},
+		{"illuminance without path", BrightnessSourceConfig{Type: BrightnessIlluminance, DarkLux: 1, BrightLux: 200}, false},
+		{"illuminance range", BrightnessSourceConfig{Type: BrightnessIlluminance, Path: "/sys/x", DarkLux: 200, BrightLux: 1}, false},
+		{"negative dark_lux", BrightnessSourceConfig{Type: BrightnessIlluminance, Path: "/sys/x", DarkLux: -5, BrightLux: 200}, false},
+		{"schedule", BrightnessSourceConfig{Type: BrightnessSchedule, Schedule: []ScheduleEntry{{At: "07:00", Level: 1}}}, true},
+		{"schedule time", BrightnessSourceConfig{Type: BrightnessSchedule, Schedule: []ScheduleEntry{{At: "7am"}}}, false},
+		{"empty schedule", BrightnessSourceConfig{Type: BrightnessSchedule}, false},
+		{"unknown", BrightnessSourceConfig{Type: "moon"}, false},
+	}
+	for _, tt := range tests {
+		bc := BrightnessConfig{Combine: "min", Sources: []BrightnessSourceConfig{tt.src}, Sun: SunConfig{Twilight: "sunrise"}}
+		if err := validateBrightness(bc); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+	if err := validateBrightness(BrightnessConfig{Combine: "median", Sun: SunConfig{Twilight: "sunrise"}}); err == nil {
+		t.Error("expected an error for an unknown combine")
+	}
+}
+
+func TestValidateBrightness_Sun(t *testing.T) {
+	tests := []struct {
+		name string
+		sun  SunConfig
+		ok   bool
+	}{
+		{"civil", SunConfig{Twilight: "civil"}, true},
+		{"keyframes", SunConfig{Twilight: "nautical", Keyframes: []ScheduleEntry{{At: "23:00", Level: 0.2}}}, true},
+		{"unknown twilight", SunConfig{Twilight: "golden"}, false},
+		{"keyframe time", SunConfig{Twilight: "civil", Keyframes: []ScheduleEntry{{At: "11pm"}}}, false},
+	}
+	for _, tt := range tests {
+		bc := BrightnessConfig{Combine: "min", Sun: tt.sun}
+		if err := validateBrightness(bc); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	for in, want := range map[string]time.Weekday{"mon": time.Monday, "Sunday": time.Sunday, "SAT": time.Saturday} {
+		if got, ok := ParseWeekday(in); !ok || got != want {
+			t.Errorf("ParseWeekday(%q): got %v, %v, want %v", in, got, ok, want)
+		}
+	}
+	if _, ok := ParseWeekday("funday"); ok {
+		t.Error("expected funday to be unknown")
+	}
+}
+
+func TestValidateQuietHours(t *testing.T) {
+	tests := []struct {
+		name string
+		w    QuietWindow
+		ok   bool
+	}{
+		{"overnight", QuietWindow{Days: []string{"mon", "friday"}, Start: "22:00", End: "07:00"}, true},
+		{"every day", QuietWindow{Start: "12:00", End: "13:00"}, true},
+		{"day", QuietWindow{Days: []string{"someday"}, Start: "22:00", End: "07:00"}, false},
+		{"start", QuietWindow{Start: "10pm", End: "07:00"}, false},
+		{"end", QuietWindow{Start: "22:00", End: "7"}, false},
+		{"empty", QuietWindow{Start: "22:00", End: "22:00"}, false},
+	}
+	for _, tt := range tests {
+		if err := validateQuietHours(QuietHoursConfig{Windows: []QuietWindow{tt.w}}); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestValidatePressure(t *testing.T) {
+	tests := []struct {
+		name string
+		pc   PressureConfig
+		ok   bool
+	}{
+		{"defaults", PressureConfig{MaxTendencyMb: 3, AltimeterRangeInHg: 0.5}, true},
+		{"negative tendency", PressureConfig{MaxTendencyMb: -3, AltimeterRangeInHg: 0.5}, false},
+		{"negative range", PressureConfig{MaxTendencyMb: 3, AltimeterRangeInHg: -0.5}, false},
+	}
+	for _, tt := range tests {
+		if err := validatePressure(tt.pc); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestValidateColors(t *testing.T) {
+	valid := func() Config {
+		c := Config{
+			Profiles: map[string]Profile{"student": {}},
+			Groups:   map[string]GroupConfig{"vor": {Leds: []int{1}, Color: "#00ffff"}},
+		}
+		setDefaults(&c)
+		return c
+	}
+	if err := validateColors(valid()); err != nil {
+		t.Fatalf("defaults: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+	}{
+		{"theme color", func(c *Config) { c.Themes["default"] = ThemeConfig{VFR: "green"} }},
+		{"profile color", func(c *Config) { c.Profiles["student"] = Profile{GoColor: "#32cd32", NoGoColor: "#ff00"} }},
+		{"pressure color", func(c *Config) { c.Pressure.FallingColor = "ff4500" }},
+		{"group color", func(c *Config) { c.Groups["vor"] = GroupConfig{Color: "#zzzzzz"} }},
+		{"group effect", func(c *Config) {
+			c.Groups["vor"] = GroupConfig{Color: "#00ffff", Effect: EffectConfig{Effect: "strobe", Color: "#ffffff"}}
+		}},
+		{"status effect", func(c *Config) { c.Status.Healthy.Effect = "heartbeat" }},
+		{"precipitation color", func(c *Config) { c.Precipitation.Effects["SN"] = EffectConfig{Effect: "twinkle", Color: "white"} }},
+	}
+	for _, tt := range tests {
+		c := valid()
+		tt.modify(&c)
+		if err := validateColors(c); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+// ParseHexColor tests
+
+func TestParseHexColor_RRGGBB(t *testing.T) {
+	c, err := ParseHexColor("#ff8800")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.R != 0xff || c.G != 0x88 || c.B != 0x00 || c.A != 0xff {
+		t.Errorf("got R=%x G=%x B=%x A=%x", c.R, c.G, c.B, c.A)
+	}
+}
+
+func TestParseHexColor_RGB(t *testing.T) {
+	c, err := ParseHexColor("#f80")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// #f80 expands to #ff8800 via *17
+	if c.R != 0xff || c.G != 0x88 || c.B != 0x00 {
+		t.Errorf("got R=%x G=%x B=%x", c.R, c.G, c.B)
+	}
+}
+
+func TestParseHexColor_Uppercase(t *testing.T) {
+	c, err := ParseHexColor("#FF0000")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.R != 0xff || c.G != 0x00 || c.B != 0x00 {
+		t.Errorf("got %+v", c)
+	}
+}
+
+func TestParseHexColor_MissingHash(t *testing.T) {
+	_, err := ParseHexColor("ff0000")
+	if err == nil {
+		t.Error("expected error for missing #, got nil")
+	}
+}
+
+func TestParseHexColor_WrongLength(t *testing.T) {
+	_, err := ParseHexColor("#ff00")
+	if err == nil {
+		t.Error("expected error for wrong length, got nil")
+	}
+}
+
+func TestParseHexColor_InvalidChars(t *testing.T) {
+	_, err := ParseHexColor("#zz0000")
+	if err == nil {
+		t.Error("expected error for invalid hex chars, got nil")
+	}
+}
+
+func TestParseHexColor_Empty(t *testing.T) {
+	_, err := ParseHexColor("")
+	if err == nil {
+		t.Error("expected error for empty string, got nil")
+	}
+}
@@ -0,0 +1,142 @@
+package display
+
+import (
+	"image/color"
+	"slices"
+	"time"
+)
+
+// Clock supplies the time to the Animator so effects can be tested deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// layer is an effect attached to one or more LEDs.
+type layer struct {
+	id     int
+	leds   []int
+	effect Effect
+	start  time.Time
+	seed   uint64
+}
+
+// Animator layers time-based effects over base LED colors. Effects attached to
+// individual LEDs or groups are stacked by Priority, lowest first; effects with
+// the same priority are stacked in the order they were attached.
+type Animator struct {
+	clock  Clock
+	seed   uint64
+	nextID int
+	layers []*layer
+	night  bool
+
+	// ledLayers tracks the layers set per LED by SetLedEffects.
+	ledLayers map[int][]int
+	// transitions tracks the crossfade layer per LED.
+	transitions map[int]int
+}
+
+func NewAnimator(clock Clock, seed uint64) *Animator {
+	return &Animator{
+		clock:       clock,
+		seed:        seed,
+		ledLayers:   make(map[int][]int),
+		transitions: make(map[int]int),
+	}
+}
+
+// Attach adds an effect across a group of LEDs and returns an id for Detach.
+func (a *Animator) Attach(leds []int, e Effect) int {
+	a.nextID++
+	l := &layer{
+		id:     a.nextID,
+		leds:   slices.Clone(leds),
+		effect: e,
+		start:  a.clock.Now(),
+		seed:   splitmix64(a.seed ^ uint64(a.nextID)),
+	}
+
+	// Insert after any layers with the same priority.
+	i := len(a.layers)
+	for j, other := range a.layers {
+		if other.effect.Priority > e.Priority {
+			i = j
+			break
+		}
+	}
+	a.layers = slices.Insert(a.layers, i, l)
+	return l.id
+}
+
+// Detach removes a layer added by Attach.
+func (a *Animator) Detach(id int) {
+	a.layers = slices.DeleteFunc(a.layers, func(l *layer) bool { return l.id == id })
+}
+
+// SetLedEffects replaces the effects previously set on a single LED.
+func (a *Animator) SetLedEffects(led int, effects []Effect) {
+	for _, id := range a.ledLayers[led] {
+		a.Detach(id)
+	}
+	delete(a.ledLayers, led)
+
+	for _, e := range effects {
+		a.ledLayers[led] = append(a.ledLayers[led], a.Attach([]int{led}, e))
+	}
+}
+
+// forget removes an expired layer from the per-LED bookkeeping.
+func (a *Animator) forget(l *layer) {
+	for _, led := range l.leds {
+		if id, ok := a.transitions[led]; ok && id == l.id {
+			delete(a.transitions, led)
+		}
+		ids := slices.DeleteFunc(a.ledLayers[led], func(id int) bool { return id == l.id })
+		if len(ids) == 0 {
+			delete(a.ledLayers, led)
+		} else {
+			a.ledLayers[led] = ids
+		}
+	}
+}
+
+// SetNight suppresses effects marked NightOff while night is true.
+func (a *Animator) SetNight(night bool) {
+	a.night = night
+}
+
+// Animating reports whether any layers are attached.
+func (a *Animator) Animating() bool {
+	return len(a.layers) > 0
+}
+
+// Apply layers the active effects over colors in place. Layers whose Until has
+// passed are dropped, along with their entries in ledLayers and transitions.
+func (a *Animator) Apply(colors []color.RGBA) {
+	now := a.clock.Now()
+
+	a.layers = slices.DeleteFunc(a.layers, func(l *layer) bool {
+		if l.effect.Until.IsZero() || now.Before(l.effect.Until) {
+			return false
+		}
+		a.forget(l)
+		return true
+	})
+
+	for _, l := range a.layers {
+		if !l.effect.active(now, a.night) {
+			continue
+		}
+		t := now.Sub(l.start)
+		for _, led := range l.leds {
+			if led < 0 || led >= len(colors) {
+				continue
+			}
+			colors[led] = l.effect.apply(colors[led], t, l.seed)
+		}
+	}
+}
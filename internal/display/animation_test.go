@@ -0,0 +1,192 @@
+package display
+
+import (
+	"image/color"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced Clock.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)}
+}
+
+func TestAnimator_Priorities(t *testing.T) {
+	clock := newFakeClock()
+	a := NewAnimator(clock, 1)
+
+	blue := color.RGBA{B: 0xff, A: 0xff}
+	// Attached out of order; the higher priority must still end up on top.
+	a.Attach([]int{0}, Effect{Kind: EffectBlink, Color: red, Rate: 1, Intensity: 1, Priority: 20})
+	a.Attach([]int{0}, Effect{Kind: EffectBlink, Color: blue, Rate: 1, Intensity: 1, Priority: 10})
+
+	colors := []color.RGBA{green}
+	a.Apply(colors)
+	if colors[0] != red {
+		t.Errorf("got %v, want the priority 20 color %v", colors[0], red)
+	}
+}
+
+func TestAnimator_Groups(t *testing.T) {
+	clock := newFakeClock()
+	a := NewAnimator(clock, 1)
+	id := a.Attach([]int{0, 2, 9}, Effect{Kind: EffectBlink, Color: red, Rate: 1, Intensity: 1})
+
+	colors := []color.RGBA{green, green, green}
+	a.Apply(colors)
+	if colors[0] != red || colors[1] != green || colors[2] != red {
+		t.Errorf("got %v, want group LEDs 0 and 2 red", colors)
+	}
+
+	a.Detach(id)
+	if a.Animating() {
+		t.Error("expected no layers after Detach")
+	}
+}
+
+func TestAnimator_EffectsStartAtAttach(t *testing.T) {
+	clock := newFakeClock()
+	a := NewAnimator(clock, 1)
+
+	// Six blinks per minute: on for 5s then off for 5s, counted from Attach.
+	clock.Advance(3 * time.Second)
+	a.Attach([]int{0}, Effect{Kind: EffectBlink, Color: red, Rate: 6, Intensity: 1})
+
+	steps := []struct {
+		advance time.Duration
+		want    color.RGBA
+	}{
+		{0, red},
+		{4 * time.Second, red},
+		{2 * time.Second, green},
+		{5 * time.Second, red},
+	}
+	for i, s := range steps {
+		clock.Advance(s.advance)
+		colors := []color.RGBA{green}
+		a.Apply(colors)
+		if colors[0] != s.want {
+			t.Errorf("step %d: got %v, want %v", i, colors[0], s.want)
+		}
+	}
+}
+
+func TestAnimator_SetLedEffectsReplaces(t *testing.T) {
+	a := NewAnimator(newFakeClock(), 1)
+	a.SetLedEffects(0, []Effect{{Kind: EffectBlink, Color: red, Rate: 1, Intensity: 1}})
+	a.SetLedEffects(0, []Effect{{Kind: EffectBlink, Color: white, Rate: 1, Intensity: 1}})
+
+	colors := []color.RGBA{green}
+	a.Apply(colors)
+	if colors[0] != white {
+		t.Errorf("got %v, want only the replacement effect", colors[0])
+	}
+
+	a.SetLedEffects(0, nil)
+	if a.Animating() {
+		t.Error("expected no layers after clearing effects")
+	}
+}
+
+func TestAnimator_UntilAndNight(t *testing.T) {
+	clock := newFakeClock()
+	a := NewAnimator(clock, 1)
+	a.Attach([]int{0}, Effect{Kind: EffectBlink, Color: red, Rate: 1, Intensity: 1, NightOff: true, Until: clock.Now().Add(time.Second)})
+
+	a.SetNight(true)
+	colors := []color.RGBA{green}
+	a.Apply(colors)
+	if colors[0] != green {
+		t.Errorf("night: got %v, want effect suppressed", colors[0])
+	}
+
+	clock.Advance(time.Second)
+	a.SetNight(false)
+	a.Apply(colors)
+	if colors[0] != green || a.Animating() {
+		t.Errorf("after Until: got %v, animating=%v; want layer dropped", colors[0], a.Animating())
+	}
+}
+
+func TestAnimator_PrunesExpired(t *testing.T) {
+	clock := newFakeClock()
+	a := NewAnimator(clock, 1)
+	a.SetLedEffects(0, []Effect{
+		{Kind: EffectBlink, Color: red, Rate: 1, Intensity: 1, Until: clock.Now().Add(time.Second)},
+		{Kind: EffectPulse, Color: red, Rate: 1, Intensity: 1},
+	})
+	a.SetLedEffects(1, []Effect{{Kind: EffectBlink, Color: red, Rate: 1, Intensity: 1, Until: clock.Now().Add(time.Second)}})
+	a.Transition(2, red, Transition{Duration: time.Second})
+
+	clock.Advance(time.Second)
+	a.Apply(make([]color.RGBA, 3))
+	if len(a.ledLayers[0]) != 1 || len(a.layers) != 1 {
+		t.Errorf("LED 0: got %d layers tracked, %d attached; want only the pulse", len(a.ledLayers[0]), len(a.layers))
+	}
+	if _, ok := a.ledLayers[1]; ok {
+		t.Error("LED 1: expired effect still tracked")
+	}
+	if len(a.transitions) != 0 {
+		t.Errorf("transitions: got %v, want the finished crossfade forgotten", a.transitions)
+	}
+}
+
+func TestLedsShow(t *testing.T) {
+	clock := newFakeClock()
+	mock := newMock(3)
+	l := newWithEngine(mock)
+	a := NewAnimator(clock, 1)
+	frame := []Pixel{{Num: 0, Color: green}, {Num: 1}, {Num: 2, Color: green}}
+	shown := make([]color.RGBA, 3)
+
+	a.SetLedEffects(2, []Effect{{Kind: EffectBlink, Color: red, Rate: 6, Intensity: 1}})
+
+	if err := l.show(frame, a, shown, 1); err != nil {
+		t.Fatal(err)
+	}
+	if mock.leds[0] != ParseRGBAtoUint32(green) || mock.leds[2] != ParseRGBAtoUint32(red) {
+		t.Errorf("leds: got %#x", mock.leds)
+	}
+	if mock.renderCalls != 1 {
+		t.Errorf("Render called %d times, want 1", mock.renderCalls)
+	}
+
+	// Nothing changes one second in, so nothing is rendered.
+	clock.Advance(time.Second)
+	l.show(frame, a, shown, 1)
+	if mock.renderCalls != 1 {
+		t.Errorf("Render called %d times, want 1 (no change)", mock.renderCalls)
+	}
+
+	// The blink turns off after 5s.
+	clock.Advance(5 * time.Second)
+	l.show(frame, a, shown, 1)
+	if mock.renderCalls != 2 || mock.leds[2] != ParseRGBAtoUint32(green) {
+		t.Errorf("after blink: renders=%d leds[2]=%#x", mock.renderCalls, mock.leds[2])
+	}
+}
+
+func TestLedsShow_Quiet(t *testing.T) {
+	mock := newMock(2)
+	l := newWithEngine(mock)
+	a := NewAnimator(newFakeClock(), 1)
+	frame := []Pixel{{Num: 0, Color: green}, {Num: 1, Color: green, QuietOn: true}}
+	shown := make([]color.RGBA, 2)
+
+	l.show(frame, a, shown, 0.5)
+	if shown[0] != BlendColors(color.RGBA{}, green, 0.5) || shown[1] != green {
+		t.Errorf("fading: got %v", shown)
+	}
+	l.show(frame, a, shown, 0)
+	if mock.leds[0] != 0 || mock.leds[1] != ParseRGBAtoUint32(green) {
+		t.Errorf("quiet: got %#x, want only the QuietOn LED lit", mock.leds)
+	}
+}
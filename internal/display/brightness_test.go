@@ -5,14 +5,14 @@ import (
 	"time"
 )
 
-func TestCalcRiseSet(t *testing.T) {
+func TestCalcCrossings(t *testing.T) {
 	loc, err := time.LoadLocation("America/Los_Angeles")
 	if err != nil {
 		t.Fatal(err)
 	}
 	now := time.Now().In(loc)
 
-	rise, set, err := calcRiseSet(now, -122.0578, 37.9884)
+	rise, set, err := calcCrossings(now, -122.0578, 37.9884, horizon)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -35,7 +35,7 @@ func TestCalcRiseSet(t *testing.T) {
 	}
 }
 
-func TestCalcBrightness(t *testing.T) {
+func TestRampBrightness(t *testing.T) {
 	base := time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC)
 	rise := base.Add(6 * time.Hour) // 06:00
 	set := base.Add(20 * time.Hour) // 20:00
@@ -96,10 +96,10 @@ func TestCalcBrightness(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := calcBrightness(tt.now, rise, set, day, night)
+			got := ScaleBrightness(curveLevel(rampPoints(rise, set, time.Hour), tt.now, EaseLinear), day, night)
 			// Allow ±1 for integer rounding
 			if got < tt.want-1 || got > tt.want+1 {
-				t.Errorf("calcBrightness at %v: got %d, want %d (±1)", tt.now.Format("15:04"), got, tt.want)
+				t.Errorf("brightness at %v: got %d, want %d (±1)", tt.now.Format("15:04"), got, tt.want)
 			}
 		})
 	}
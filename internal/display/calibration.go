@@ -0,0 +1,65 @@
+package display
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+// Calibration adjusts individual LEDs that look dimmer or tinted than the rest.
+// It is applied to theme colors before ColorCorrection, by LED number.
+type Calibration map[int]Adjustment
+
+// Adjustment scales an LED's color by Brightness, then adds a per-channel offset.
+type Adjustment struct {
+	Brightness float64
+	Red        int
+	Green      int
+	Blue       int
+}
+
+// CalibrationFromConfig combines the group and LED calibrations for each LED:
+// brightness multipliers are multiplied and offsets added.
+func CalibrationFromConfig(c config.Config) Calibration {
+	cal := make(Calibration)
+	add := func(num int, lc config.LedCalibration) {
+		a, ok := cal[num]
+		if !ok {
+			a.Brightness = 1
+		}
+		a.Brightness *= lc.Multiplier()
+		a.Red += lc.Red
+		a.Green += lc.Green
+		a.Blue += lc.Blue
+		cal[num] = a
+	}
+	for name, lc := range c.Calibration.Groups {
+		for _, num := range c.Groups[name].LedNumbers() {
+			add(num, lc)
+		}
+	}
+	for num, lc := range c.Calibration.Leds {
+		add(num, lc)
+	}
+	return cal
+}
+
+// Apply returns the calibrated color of LED num. Off LEDs stay off, so offsets
+// never light an LED that should be dark.
+func (cal Calibration) Apply(num int, c color.RGBA) color.RGBA {
+	a, ok := cal[num]
+	if !ok || (c.R == 0 && c.G == 0 && c.B == 0) {
+		return c
+	}
+	adjust := func(v uint8, offset int) uint8 {
+		out := math.Round(float64(v)*a.Brightness) + float64(offset)
+		return uint8(math.Min(math.Max(out, 0), 255))
+	}
+	return color.RGBA{
+		R: adjust(c.R, a.Red),
+		G: adjust(c.G, a.Green),
+		B: adjust(c.B, a.Blue),
+		A: c.A,
+	}
+}
@@ -0,0 +1,69 @@
+package display
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+func multiplier(v float64) *float64 { return &v }
+
+func TestCalibrationFromConfig(t *testing.T) {
+	c := config.Config{
+		Groups: map[string]config.GroupConfig{"vors": {Leds: []int{4, 5}}},
+		Calibration: config.CalibrationConfig{
+			Leds:   map[int]config.LedCalibration{5: {Brightness: multiplier(2), Red: 10}, 7: {Blue: -5}, 9: {Brightness: multiplier(0)}},
+			Groups: map[string]config.LedCalibration{"vors": {Brightness: multiplier(0.5), Red: 3}},
+		},
+	}
+	cal := CalibrationFromConfig(c)
+
+	want := Calibration{
+		4: {Brightness: 0.5, Red: 3},
+		5: {Brightness: 1, Red: 13},
+		7: {Brightness: 1, Blue: -5},
+		9: {Brightness: 0},
+	}
+	if len(cal) != len(want) {
+		t.Fatalf("got %+v, want %+v", cal, want)
+	}
+	for num, a := range want {
+		if cal[num] != a {
+			t.Errorf("LED %d: got %+v, want %+v", num, cal[num], a)
+		}
+	}
+}
+
+func TestCalibrationApply(t *testing.T) {
+	cal := Calibration{1: {Brightness: 1.5, Red: 20, Blue: -300}}
+	grey := color.RGBA{R: 100, G: 100, B: 100, A: 0xff}
+
+	if got, want := cal.Apply(1, grey), (color.RGBA{R: 170, G: 150, B: 0, A: 0xff}); got != want {
+		t.Errorf("calibrated: got %v, want %v", got, want)
+	}
+	if got := cal.Apply(1, color.RGBA{R: 200, A: 0xff}); got.R != 255 {
+		t.Errorf("clamped: got %v, want R=255", got)
+	}
+	if got := cal.Apply(0, grey); got != grey {
+		t.Errorf("uncalibrated LED: got %v, want %v", got, grey)
+	}
+	if got := cal.Apply(1, color.RGBA{}); got != (color.RGBA{}) {
+		t.Errorf("off LED: got %v, want it to stay off", got)
+	}
+	if got := Calibration(nil).Apply(1, grey); got != grey {
+		t.Errorf("nil calibration: got %v, want %v", got, grey)
+	}
+}
+
+func TestDisplay_CalibrationBeforeCorrection(t *testing.T) {
+	m := newMock(2)
+	leds := newWithEngine(m)
+	leds.Calibration = Calibration{1: {Brightness: 0.5}}
+	leds.Correction = NewColorCorrection(1, 1, 1, 0)
+
+	leds.Display(1, color.RGBA{R: 200, G: 200, B: 200, A: 0xff})
+	if got, want := m.leds[1], ParseRGBAtoUint32(color.RGBA{R: 100, G: 100, A: 0xff}); got != want {
+		t.Errorf("got %#x, want %#x", got, want)
+	}
+}
@@ -0,0 +1,48 @@
+package display
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+// ColorCorrection maps sRGB colors onto the LED response with a gamma curve and
+// per-channel scale factors for white balance.
+type ColorCorrection struct {
+	Gamma float64
+	Red   float64
+	Green float64
+	Blue  float64
+
+	lut [3][256]uint8
+}
+
+func NewColorCorrection(gamma, red, green, blue float64) *ColorCorrection {
+	cc := &ColorCorrection{Gamma: gamma, Red: red, Green: green, Blue: blue}
+	for ch, scale := range []float64{red, green, blue} {
+		for v := 0; v < 256; v++ {
+			out := math.Pow(float64(v)/255, gamma) * scale * 255
+			cc.lut[ch][v] = uint8(math.Round(math.Min(math.Max(out, 0), 255)))
+		}
+	}
+	return cc
+}
+
+// ColorCorrectionFromConfig builds the correction stage from config settings.
+func ColorCorrectionFromConfig(c config.ColorCorrectionConfig) *ColorCorrection {
+	return NewColorCorrection(c.Gamma, c.Red, c.Green, c.Blue)
+}
+
+// Apply returns the corrected color; a nil ColorCorrection leaves colors unchanged.
+func (cc *ColorCorrection) Apply(c color.RGBA) color.RGBA {
+	if cc == nil {
+		return c
+	}
+	return color.RGBA{
+		R: cc.lut[0][c.R],
+		G: cc.lut[1][c.G],
+		B: cc.lut[2][c.B],
+		A: c.A,
+	}
+}
@@ -0,0 +1,60 @@
+package display
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+func TestColorCorrection_Identity(t *testing.T) {
+	cc := NewColorCorrection(1, 1, 1, 1)
+	for _, c := range []color.RGBA{{}, {R: 0x12, G: 0x34, B: 0x56, A: 0xff}, white} {
+		if got := cc.Apply(c); got != c {
+			t.Errorf("Apply(%v) = %v, want unchanged", c, got)
+		}
+	}
+}
+
+func TestColorCorrection_Gamma(t *testing.T) {
+	cc := NewColorCorrection(2.2, 1, 1, 1)
+
+	got := cc.Apply(color.RGBA{R: 128, G: 128, B: 128, A: 0xff})
+	// (128/255)^2.2 * 255 ≈ 56
+	if got.R < 54 || got.R > 58 || got.R != got.G || got.G != got.B {
+		t.Errorf("mid grey: got %v, want about 56 on every channel", got)
+	}
+	if got := cc.Apply(white); got != white {
+		t.Errorf("white: got %v, want unchanged", got)
+	}
+	if got := cc.Apply(color.RGBA{A: 0xff}); got != (color.RGBA{A: 0xff}) {
+		t.Errorf("black: got %v, want unchanged", got)
+	}
+}
+
+func TestColorCorrection_WhiteBalance(t *testing.T) {
+	cc := NewColorCorrection(1, 1, 0.8, 2)
+	got := cc.Apply(color.RGBA{R: 200, G: 200, B: 200, A: 0xff})
+	if got.R != 200 || got.G != 160 || got.B != 255 {
+		t.Errorf("got %v, want R=200 G=160 B=255 (clamped)", got)
+	}
+}
+
+func TestColorCorrection_Nil(t *testing.T) {
+	var cc *ColorCorrection
+	c := color.RGBA{R: 1, G: 2, B: 3, A: 0xff}
+	if got := cc.Apply(c); got != c {
+		t.Errorf("nil correction: got %v, want unchanged", got)
+	}
+}
+
+func TestLedsDisplay_AppliesCorrection(t *testing.T) {
+	mock := newMock(1)
+	l := newWithEngine(mock)
+	l.Correction = ColorCorrectionFromConfig(config.ColorCorrectionConfig{Gamma: 1, Red: 0.5, Green: 1, Blue: 1})
+
+	l.Display(0, color.RGBA{R: 200, G: 100, B: 50, A: 0xff})
+	if want := uint32(100)<<16 | 100<<8 | 50; mock.leds[0] != want {
+		t.Errorf("leds[0] = %#x, want %#x", mock.leds[0], want)
+	}
+}
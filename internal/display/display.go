@@ -1,8 +1,11 @@
 package display
 
 import (
-	"errors"
+	"fmt"
 	"image/color"
+	"io"
+	"os"
+	"slices"
 	"time"
 
 	"github.com/finack/twinkle/internal/config"
@@ -19,21 +22,51 @@ type wsEngine interface {
 }
 
 type Leds struct {
-	Ws wsEngine
+	Ws          wsEngine
+	Calibration Calibration      // per-LED adjustments applied before Correction; nil disables
+	Correction  *ColorCorrection // applied to every color written by Display; nil disables
+	Power       PowerModel       // estimates the draw on each Render, dimming to stay within budget
+
+	// addresses maps global LED numbers onto channels; nil addresses channel 0 directly.
+	addresses []address
+	// white holds the white extraction per channel; channels beyond it are RGB.
+	white []WhiteExtraction
+	// brightness is the requested brightness per channel, and effective the
+	// brightness last sent to the strip after power limiting.
+	brightness []int
+	effective  []int
+	estimate   PowerEstimate
 }
 
 type Pixel struct {
-	Num   int
-	Color color.RGBA
+	Num     int
+	Color   color.RGBA
+	Effects []Effect
+	QuietOn bool // stays lit through quiet hours
 }
 
 func newWithEngine(ws wsEngine) *Leds {
 	return &Leds{Ws: ws}
 }
 
-func UpdateRoutine(c config.Config) (chan bool, chan Pixel) {
+// configure addresses the channels and segments in hw.
+func (l *Leds) configure(hw config.HardwareConfig) {
+	l.addresses = addressMap(hw.Segments)
+	l.white = whiteExtractions(hw)
+	l.brightness = nil
+	for _, ch := range hw.Channels {
+		l.brightness = append(l.brightness, ch.Brightness)
+	}
+	l.effective = slices.Clone(l.brightness)
+}
+
+// UpdateRoutine drives the LEDs from frames submitted to the returned Renderer,
+// rendering once per change and stepping any effects every AnimationTickMS, and
+// fades them out during quiet. The returned Monitor mirrors what is shown.
+func UpdateRoutine(c config.Config, quiet *QuietHours) (chan bool, *Renderer, *Monitor) {
 	done := make(chan bool)
-	ledChannel := make(chan Pixel)
+	renderer := NewRenderer(c.LedCount)
+	monitor := NewMonitor(c.Hardware, time.Duration(c.Simulator.HistoryS)*time.Second)
 
 	loc, err := time.LoadLocation(c.Locale)
 	if err != nil {
@@ -43,131 +76,315 @@ func UpdateRoutine(c config.Config) (chan bool, chan Pixel) {
 	// Extract only the scalars needed so the goroutine closure doesn't retain
 	// the Leds/Stations maps for the process lifetime.
 	ledCount := c.LedCount
-	ledRefreshRateMS := c.LedRefreshRateMS
-	brightness := c.Brightness
-	nightBrightness := c.NightBrightness
-	latitude := c.Latitude
-	longitude := c.Longitude
+	animationTickMS := c.AnimationTickMS
+	hardware := c.Hardware
+	transition := transitionFromConfig(c.Transition)
+	correction := ColorCorrectionFromConfig(c.ColorCorrection)
+	calibration := CalibrationFromConfig(c)
 
-	go func() {
-		display := make([]color.RGBA, ledCount)
-		var buffer []Pixel
+	sun, err := NewSunCurve(c.Latitude, c.Longitude, c.BrightnessControl.Sun)
+	if err != nil {
+		log.Fatal().Err(err).Caller().Msg("Invalid brightness_control sun")
+	}
+	brightness, err := BrightnessFromConfig(c.BrightnessControl, sun)
+	if err != nil {
+		log.Fatal().Err(err).Caller().Msg("Invalid brightness_control")
+	}
 
-		leds, err := New(brightness, ledCount)
-		if err != nil {
-			log.Fatal().Err(err).Caller().Msg("Could not start connection to LEDS")
-		}
+	groups, err := groupFrame(c.Groups)
+	if err != nil {
+		log.Fatal().Err(err).Caller().Msg("Invalid LED group")
+	}
+	if err := renderer.Submit(groups); err != nil {
+		log.Warn().Err(err).Msg("Groups mapped outside the LED strip")
+	}
+
+	leds, err := Open(c)
+	if err != nil {
+		log.Fatal().Err(err).Caller().Msg("Could not start connection to LEDS")
+	}
+	leds.Correction = correction
+	leds.Calibration = calibration
+	monitor.observe(leds, time.Now())
 
-		ledRefreshRate := time.NewTicker(time.Duration(ledRefreshRateMS) * time.Millisecond)
-		defer ledRefreshRate.Stop()
+	go func() {
+		frame := make([]Pixel, ledCount)
+		shown := make([]color.RGBA, ledCount)
+		animator := NewAnimator(systemClock{}, uint64(time.Now().UnixNano()))
+
+		animationTick := time.NewTicker(time.Duration(animationTickMS) * time.Millisecond)
+		defer animationTick.Stop()
 
 		brightnessRefresh := time.NewTicker(10 * time.Second)
 		defer brightnessRefresh.Stop()
+		// brightnessErr is the last brightness source error, logged only when it changes.
+		var brightnessErr string
+
+		// Quiet hours fade the LEDs out, so check often enough to fade smoothly.
+		quietRefresh := time.NewTicker(time.Second)
+		defer quietRefresh.Stop()
 
-		var (
-			riseSetDate time.Time
-			cachedRise  time.Time
-			cachedSet   time.Time
-		)
+		// The strip starts at day brightness, fully on.
+		brightnessLevel, quietLevel := 1.0, 1.0
+		applyBrightness := func(now time.Time) {
+			for ch, cc := range hardware.Channels {
+				b := ScaleBrightness(brightnessLevel, cc.Brightness, cc.NightBrightness)
+				leds.SetBrightness(ch, b)
+				log.Debug().Int("channel", ch).Float64("level", brightnessLevel).Int("brightness", b).Msg("Updated brightness")
+			}
+			if err := leds.Render(); err != nil {
+				log.Error().Err(err).Caller().Msg("Issue rendering brightness change")
+			}
+			monitor.observe(leds, now)
+			est := leds.Estimate()
+			log.Debug().
+				Float64("requestedMA", est.RequestedMA).
+				Float64("drawMA", est.DrawMA).
+				Float64("scale", est.Scale).
+				Msg("Estimated power draw")
+		}
 
 		for {
+			// quietRefresh brings the loop round every second, so the beats stop
+			// only if rendering hangs.
+			renderer.beat(time.Now())
 			select {
 			case <-done:
 				leds.Clear()
 				leds.Ws.Fini()
 				return
-			case m := <-ledChannel:
-				if display[m.Num] != m.Color {
-					buffer = append(buffer, m)
+			case <-renderer.Changed():
+				previous := slices.Clone(frame)
+				dirty := diff(frame, renderer.take())
+				if len(dirty) == 0 {
+					continue
+				}
+
+				log.Debug().Int("ledCount", len(dirty)).Msg("Updating Display")
+				for _, num := range dirty {
+					if frame[num].Color != previous[num].Color {
+						animator.Transition(num, shown[num], transition)
+					}
+					animator.SetLedEffects(num, frame[num].Effects)
+				}
+				if err := leds.show(frame, animator, shown, quietLevel); err != nil {
+					log.Error().Err(err).Caller().Msg("Issue rendering to LEDS")
 				}
+				monitor.record(shown, time.Now())
+				monitor.observe(leds, time.Now())
 			case <-brightnessRefresh.C:
 				now := time.Now().In(loc)
-				today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
-				if !today.Equal(riseSetDate) {
-					cachedRise, cachedSet, err = calcRiseSet(now, longitude, latitude)
-					if err != nil {
-						continue
-					}
-					riseSetDate = today
+				animator.SetNight(sun.Night(now))
+				level, ok, err := brightness.Update(now)
+				if err != nil && err.Error() != brightnessErr {
+					log.Warn().Err(err).Msg("Could not read brightness source")
 				}
-				b := calcBrightness(now, cachedRise, cachedSet, brightness, nightBrightness)
-				leds.Ws.SetBrightness(0, b)
-				if err := leds.Ws.Render(); err != nil {
-					log.Error().Err(err).Caller().Msg("Issue rendering brightness change")
+				brightnessErr = ""
+				if err != nil {
+					brightnessErr = err.Error()
 				}
-				log.Debug().Int("brightness", b).Msg("Updated brightness")
-			case <-ledRefreshRate.C:
-				if len(buffer) == 0 {
+				if !ok {
 					continue
 				}
-
-				log.Debug().Int("ledCount", len(buffer)).Msg("Updating Display")
-				for _, p := range buffer {
-					leds.Display(p.Num, p.Color)
-					display[p.Num] = p.Color
+				brightnessLevel = level
+				applyBrightness(now)
+			case <-quietRefresh.C:
+				now := time.Now().In(loc)
+				level := quiet.Level(now)
+				if level == quietLevel {
+					continue
+				}
+				switch {
+				case quietLevel == 1:
+					log.Info().Msg("Quiet hours starting, fading out")
+				case level == 1:
+					log.Info().Msg("Quiet hours over")
 				}
-				if err := leds.Ws.Render(); err != nil {
+				quietLevel = level
+				if err := leds.show(frame, animator, shown, quietLevel); err != nil {
 					log.Error().Err(err).Caller().Msg("Issue rendering to LEDS")
 				}
-				buffer = nil
+				monitor.record(shown, now)
+				monitor.observe(leds, now)
+			case <-animationTick.C:
+				if !animator.Animating() {
+					continue
+				}
+				if err := leds.show(frame, animator, shown, quietLevel); err != nil {
+					log.Error().Err(err).Caller().Msg("Issue rendering to LEDS")
+				}
+				monitor.record(shown, time.Now())
+				monitor.observe(leds, time.Now())
 			}
 		}
 	}()
 
-	return done, ledChannel
+	return done, renderer, monitor
+}
+
+// Open checks the hardware config, so every backend rejects a layout the strip
+// couldn't drive, then connects to the LEDs through the configured backend.
+func Open(c config.Config) (*Leds, error) {
+	if err := ValidateHardware(c.Hardware); err != nil {
+		return nil, fmt.Errorf("hardware config: %w", err)
+	}
+
+	var (
+		leds *Leds
+		err  error
+	)
+	switch c.Backend {
+	case config.BackendWS281x:
+		leds, err = New(c.Hardware)
+	case config.BackendTerminal:
+		leds, err = NewTerminal(os.Stdout, c.Hardware, c.Leds)
+	case config.BackendHeadless:
+		leds, err = NewTerminal(io.Discard, c.Hardware, c.Leds)
+	default:
+		err = fmt.Errorf("unknown backend %q, use one of %v", c.Backend, config.Backends)
+	}
+	if err != nil {
+		return nil, err
+	}
+	leds.Power = PowerModelFromConfig(c.Power)
+	return leds, nil
+}
+
+// show writes the frame with animations applied to the LEDs, rendering only if an
+// LED changed. shown holds the colors currently on the strip and is updated.
+func (l *Leds) show(frame []Pixel, a *Animator, shown []color.RGBA, quiet float64) error {
+	colors := make([]color.RGBA, len(frame))
+	for i, p := range frame {
+		colors[i] = p.Color
+	}
+	a.Apply(colors)
+	for i, p := range frame {
+		if !p.QuietOn && quiet < 1 {
+			colors[i] = BlendColors(color.RGBA{}, colors[i], quiet)
+		}
+	}
+
+	changed := false
+	for i, col := range colors {
+		if col == shown[i] {
+			continue
+		}
+		l.Display(i, col)
+		shown[i] = col
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return l.Render()
+}
+
+// SetBrightness sets the brightness of a channel from the next Render.
+func (l *Leds) SetBrightness(channel, brightness int) {
+	for len(l.brightness) <= channel {
+		l.brightness = append(l.brightness, 0)
+	}
+	l.brightness[channel] = brightness
+}
+
+// Render sends the LED values to the strip, first dimming every channel in
+// proportion if the estimated draw is over the power budget.
+func (l *Leds) Render() error {
+	var strips [][]uint32
+	for _, ch := range l.channels() {
+		for len(strips) <= ch {
+			strips = append(strips, nil)
+		}
+		strips[ch] = l.Ws.Leds(ch)
+	}
+
+	est, effective := l.Power.limit(strips, l.brightness)
+	for ch, b := range effective {
+		if ch >= len(l.effective) || l.effective[ch] != b {
+			l.Ws.SetBrightness(ch, b)
+		}
+	}
+	l.effective = effective
+
+	if est.Limited() && !l.estimate.Limited() {
+		log.Warn().
+			Float64("requestedMA", est.RequestedMA).
+			Float64("budgetMA", est.BudgetMA).
+			Float64("scale", est.Scale).
+			Msg("Dimming LEDs to stay within power budget")
+	} else if !est.Limited() && l.estimate.Limited() {
+		log.Info().Float64("drawMA", est.DrawMA).Msg("LEDs back within power budget")
+	}
+	l.estimate = est
+
+	return l.Ws.Render()
+}
+
+// Estimate returns the power draw estimated by the last Render.
+func (l *Leds) Estimate() PowerEstimate {
+	return l.estimate
+}
+
+// transitionFromConfig converts the crossfade settings, falling back to linear
+// easing if the configured curve is unknown.
+func transitionFromConfig(c config.TransitionConfig) Transition {
+	if c.Disabled {
+		return Transition{}
+	}
+	easing, err := ParseEasing(c.Easing)
+	if err != nil {
+		log.Warn().Err(err).Msg("Invalid transition easing, using linear")
+	}
+	return Transition{Duration: time.Duration(c.DurationMS) * time.Millisecond, Easing: easing}
 }
 
 func (l *Leds) Clear() error {
-	for i := 0; i < len(l.Ws.Leds(0)); i++ {
-		l.Ws.Leds(0)[i] = 0
-		if err := l.Ws.Render(); err != nil {
-			return err
+	for _, ch := range l.channels() {
+		strip := l.Ws.Leds(ch)
+		for i := range strip {
+			strip[i] = 0
+			if err := l.Ws.Render(); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
+// Display sets the color of LED num, numbered across all segments.
 func (l *Leds) Display(num int, c color.RGBA) {
-	l.Ws.Leds(0)[num] = ParseRGBAtoUint32(c)
+	ch, idx := l.address(num)
+	white := WhiteOff
+	if ch < len(l.white) {
+		white = l.white[ch]
+	}
+	l.Ws.Leds(ch)[idx] = white.pack(l.Correction.Apply(l.Calibration.Apply(num, c)))
 }
 
-func ParseRGBAtoUint32(c color.RGBA) uint32 {
-	return uint32(c.R)<<16 | uint32(c.G)<<8 | uint32(c.B)
+// address returns the channel and index on that channel of LED num.
+func (l *Leds) address(num int) (int, int) {
+	if l.addresses == nil {
+		return 0, num
+	}
+	a := l.addresses[num]
+	return a.channel, a.index
 }
 
-// From https://stackoverflow.com/questions/54197913/parse-hex-string-to-image-color
-func ParseHexColor(s string) (c color.RGBA, err error) {
-	errInvalidFormat := errors.New("image is not a valid format")
-	c.A = 0xff
-
-	if len(s) == 0 || s[0] != '#' {
-		return c, errInvalidFormat
+// channels lists the channels with addressed LEDs, in ascending order.
+func (l *Leds) channels() []int {
+	if l.addresses == nil {
+		return []int{0}
 	}
-
-	hexToByte := func(b byte) byte {
-		switch {
-		case b >= '0' && b <= '9':
-			return b - '0'
-		case b >= 'a' && b <= 'f':
-			return b - 'a' + 10
-		case b >= 'A' && b <= 'F':
-			return b - 'A' + 10
+	var chs []int
+	for _, a := range l.addresses {
+		if !slices.Contains(chs, a.channel) {
+			chs = append(chs, a.channel)
 		}
-		err = errInvalidFormat
-		return 0
-	}
-
-	switch len(s) {
-	case 7:
-		c.R = hexToByte(s[1])<<4 + hexToByte(s[2])
-		c.G = hexToByte(s[3])<<4 + hexToByte(s[4])
-		c.B = hexToByte(s[5])<<4 + hexToByte(s[6])
-	case 4:
-		c.R = hexToByte(s[1]) * 17
-		c.G = hexToByte(s[2]) * 17
-		c.B = hexToByte(s[3]) * 17
-	default:
-		err = errInvalidFormat
 	}
-	return
+	slices.Sort(chs)
+	return chs
+}
+
+func ParseRGBAtoUint32(c color.RGBA) uint32 {
+	return uint32(c.R)<<16 | uint32(c.G)<<8 | uint32(c.B)
 }
@@ -5,15 +5,44 @@ package display
 import (
 	"fmt"
 
+	"github.com/finack/twinkle/internal/config"
+
 	ws2811 "github.com/rpi-ws281x/rpi-ws281x-go"
 )
 
-func New(brightness int, ledcount int) (*Leds, error) {
+var stripTypeValues = map[string]int{
+	"rgb": ws2811.WS2811StripRGB,
+	"rbg": ws2811.WS2811StripRBG,
+	"grb": ws2811.WS2811StripGRB,
+	"gbr": ws2811.WS2811StripGBR,
+	"brg": ws2811.WS2811StripBRG,
+	"bgr": ws2811.WS2811StripBGR,
+
+	"rgbw": ws2811.SK6812StripRGBW,
+	"rbgw": ws2811.SK6812StripRBGW,
+	"grbw": ws2811.SK6812StripGRBW,
+	"gbrw": ws2811.SK6812StrioGBRW,
+	"brgw": ws2811.SK6812StrioBRGW,
+	"bgrw": ws2811.SK6812StripBGRW,
+}
+
+// New drives the strips in hw, which should have passed ValidateHardware.
+func New(hw config.HardwareConfig) (*Leds, error) {
 	opt := ws2811.DefaultOptions
-	opt.Channels[0].Brightness = brightness
-	opt.Channels[0].LedCount = ledcount
-	opt.Channels[0].StripeType = ws2811.WS2811StripRGB
-	opt.Channels[0].GpioPin = 12
+	opt.Frequency = hw.Frequency
+	opt.DmaNum = hw.DMA
+	// Gamma is left unset so the driver output is linear; ColorCorrection applies
+	// the configured curve instead.
+	opt.Channels = make([]ws2811.ChannelOption, ws2811.RpiPwmChannels)
+	for i, ch := range hw.Channels {
+		opt.Channels[i] = ws2811.ChannelOption{
+			GpioPin:    ch.GpioPin,
+			Invert:     ch.Invert,
+			LedCount:   ch.LedCount,
+			StripeType: stripTypeValues[ch.StripType],
+			Brightness: ch.Brightness,
+		}
+	}
 
 	dev, err := ws2811.MakeWS2811(&opt)
 	if err != nil {
@@ -25,6 +54,7 @@ func New(brightness int, ledcount int) (*Leds, error) {
 	}
 
 	leds := newWithEngine(dev)
+	leds.configure(hw)
 	leds.Clear()
 	return leds, nil
 }
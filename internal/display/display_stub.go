@@ -2,8 +2,12 @@
 
 package display
 
-import "errors"
+import (
+	"errors"
 
-func New(brightness int, ledcount int) (*Leds, error) {
-	return nil, errors.New("LED hardware only available on Linux/Raspberry Pi")
+	"github.com/finack/twinkle/internal/config"
+)
+
+func New(hw config.HardwareConfig) (*Leds, error) {
+	return nil, errors.New("LED hardware only available on Linux/Raspberry Pi, use the terminal backend")
 }
@@ -8,6 +8,8 @@ import (
 // mockWsEngine satisfies the wsEngine interface for tests.
 type mockWsEngine struct {
 	leds        []uint32
+	channel1    []uint32
+	brightness  map[int]int
 	renderErr   error
 	renderCalls int
 }
@@ -16,68 +18,29 @@ func newMock(size int) *mockWsEngine {
 	return &mockWsEngine{leds: make([]uint32, size)}
 }
 
-func (m *mockWsEngine) Init() error                           { return nil }
-func (m *mockWsEngine) Fini()                                 {}
-func (m *mockWsEngine) Wait() error                           { return nil }
-func (m *mockWsEngine) SetBrightness(channel, brightness int) {}
-func (m *mockWsEngine) Leds(channel int) []uint32             { return m.leds }
-func (m *mockWsEngine) Render() error {
-	m.renderCalls++
-	return m.renderErr
+// newTwoChannelMock returns a mock with LEDs on both channels.
+func newTwoChannelMock(size0, size1 int) *mockWsEngine {
+	return &mockWsEngine{leds: make([]uint32, size0), channel1: make([]uint32, size1)}
 }
 
-// ParseHexColor tests
-
-func TestParseHexColor_RRGGBB(t *testing.T) {
-	c, err := ParseHexColor("#ff8800")
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	if c.R != 0xff || c.G != 0x88 || c.B != 0x00 || c.A != 0xff {
-		t.Errorf("got R=%x G=%x B=%x A=%x", c.R, c.G, c.B, c.A)
+func (m *mockWsEngine) Init() error { return nil }
+func (m *mockWsEngine) Fini()       {}
+func (m *mockWsEngine) Wait() error { return nil }
+func (m *mockWsEngine) SetBrightness(channel, brightness int) {
+	if m.brightness == nil {
+		m.brightness = make(map[int]int)
 	}
+	m.brightness[channel] = brightness
 }
-
-func TestParseHexColor_RGB(t *testing.T) {
-	c, err := ParseHexColor("#f80")
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	// #f80 expands to #ff8800 via *17
-	if c.R != 0xff || c.G != 0x88 || c.B != 0x00 {
-		t.Errorf("got R=%x G=%x B=%x", c.R, c.G, c.B)
+func (m *mockWsEngine) Leds(channel int) []uint32 {
+	if channel == 1 {
+		return m.channel1
 	}
+	return m.leds
 }
-
-func TestParseHexColor_Uppercase(t *testing.T) {
-	c, err := ParseHexColor("#FF0000")
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	if c.R != 0xff || c.G != 0x00 || c.B != 0x00 {
-		t.Errorf("got %+v", c)
-	}
-}
-
-func TestParseHexColor_MissingHash(t *testing.T) {
-	_, err := ParseHexColor("ff0000")
-	if err == nil {
-		t.Error("expected error for missing #, got nil")
-	}
-}
-
-func TestParseHexColor_WrongLength(t *testing.T) {
-	_, err := ParseHexColor("#ff00")
-	if err == nil {
-		t.Error("expected error for wrong length, got nil")
-	}
-}
-
-func TestParseHexColor_InvalidChars(t *testing.T) {
-	_, err := ParseHexColor("#zz0000")
-	if err == nil {
-		t.Error("expected error for invalid hex chars, got nil")
-	}
+func (m *mockWsEngine) Render() error {
+	m.renderCalls++
+	return m.renderErr
 }
 
 // ParseRGBAtoUint32 tests
@@ -144,10 +107,3 @@ func TestLedsClear(t *testing.T) {
 		t.Errorf("Render called %d times, want %d (once per LED)", mock.renderCalls, len(mock.leds))
 	}
 }
-
-func TestParseHexColor_Empty(t *testing.T) {
-	_, err := ParseHexColor("")
-	if err == nil {
-		t.Error("expected error for empty string, got nil")
-	}
-}
@@ -0,0 +1,248 @@
+package display
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+type EffectKind int
+
+const (
+	EffectNone EffectKind = iota
+	// EffectFlash briefly drives the LED toward the effect color at random intervals.
+	EffectFlash
+	// EffectFlicker is a softer, shorter variant of EffectFlash.
+	EffectFlicker
+	// EffectTwinkle sparkles toward the effect color at random levels, holding each
+	// sparkle for a few slots.
+	EffectTwinkle
+	// EffectPulse smoothly swells toward the effect color Rate times per minute.
+	EffectPulse
+	// EffectBlink switches between the base and effect colors Rate times per minute.
+	EffectBlink
+	// EffectBlip is a brief blink, shown for a tenth of each cycle.
+	EffectBlip
+	// EffectBreathe is a pulse that lingers at the bottom of each cycle.
+	EffectBreathe
+	// EffectSparkle is a single-slot glint at a random level.
+	EffectSparkle
+	// EffectFade starts at the effect color and fades to the base color over Duration.
+	EffectFade
+	// EffectSolid holds the effect color steadily, so pairing it with NightOff
+	// shows the color only during the day.
+	EffectSolid
+)
+
+var effectNames = map[string]EffectKind{
+	"flash":   EffectFlash,
+	"flicker": EffectFlicker,
+	"twinkle": EffectTwinkle,
+	"pulse":   EffectPulse,
+	"blink":   EffectBlink,
+	"blip":    EffectBlip,
+	"breathe": EffectBreathe,
+	"sparkle": EffectSparkle,
+	"fade":    EffectFade,
+	"solid":   EffectSolid,
+}
+
+// ParseEffectKind converts a config effect name such as "pulse" into an EffectKind.
+func ParseEffectKind(s string) (EffectKind, error) {
+	k, ok := effectNames[strings.ToLower(s)]
+	if !ok {
+		return EffectNone, fmt.Errorf("unknown effect %q", s)
+	}
+	return k, nil
+}
+
+// EffectFromConfig converts an EffectConfig into an Effect.
+func EffectFromConfig(ec config.EffectConfig) (Effect, error) {
+	kind, err := ParseEffectKind(ec.Effect)
+	if err != nil {
+		return Effect{}, err
+	}
+	col, err := config.ParseHexColor(ec.Color)
+	if err != nil {
+		return Effect{}, err
+	}
+	return Effect{
+		Kind:      kind,
+		Color:     col,
+		Rate:      ec.Rate,
+		Intensity: ec.Intensity,
+	}, nil
+}
+
+// Easing shapes the progress of an EffectFade.
+type Easing int
+
+const (
+	EaseLinear Easing = iota
+	EaseIn
+	EaseOut
+	EaseInOut
+)
+
+var easingNames = map[string]Easing{
+	"linear":      EaseLinear,
+	"ease_in":     EaseIn,
+	"ease_out":    EaseOut,
+	"ease_in_out": EaseInOut,
+}
+
+// ParseEasing converts a config easing name such as "ease_in_out" into an Easing.
+func ParseEasing(s string) (Easing, error) {
+	e, ok := easingNames[strings.ToLower(s)]
+	if !ok {
+		return EaseLinear, fmt.Errorf("unknown easing %q", s)
+	}
+	return e, nil
+}
+
+// ease maps linear progress x in [0, 1] onto the easing curve.
+func (e Easing) ease(x float64) float64 {
+	switch e {
+	case EaseIn:
+		return x * x
+	case EaseOut:
+		return 1 - (1-x)*(1-x)
+	case EaseInOut:
+		return (1 - math.Cos(math.Pi*x)) / 2
+	default:
+		return x
+	}
+}
+
+// Effect is an animated overlay layered on top of a Pixel's base color.
+type Effect struct {
+	Kind      EffectKind
+	Color     color.RGBA
+	Rate      float64       // events or cycles per minute
+	Intensity float64       // 0-1 blend toward Color at the peak of an event
+	Duration  time.Duration // length of an EffectFade
+	Easing    Easing        // progress curve of an EffectFade
+	Priority  int           // higher priorities are layered on top
+	NightOff  bool          // suppress the effect between sunset and sunrise
+	Until     time.Time     // the effect ends at this time; zero runs until replaced
+}
+
+// active reports whether the effect should be shown at now.
+func (e Effect) active(now time.Time, night bool) bool {
+	if e.NightOff && night {
+		return false
+	}
+	return e.Until.IsZero() || now.Before(e.Until)
+}
+
+// Random effects decide whether an event happens in each slot of this length, so
+// their output depends only on elapsed time and the layer seed.
+const (
+	eventSlot   = 100 * time.Millisecond
+	flickerSlot = 50 * time.Millisecond
+)
+
+// level returns how far toward Color the effect is, 0-1, at elapsed time t.
+func (e Effect) level(t time.Duration, seed uint64) float64 {
+	cycles := e.Rate * t.Minutes()
+	phase := cycles - math.Floor(cycles)
+
+	switch e.Kind {
+	case EffectPulse:
+		return e.Intensity * (1 - math.Cos(2*math.Pi*phase)) / 2
+	case EffectBreathe:
+		// exp(-cos) breathing curve, normalised to 0-1 and starting at the bottom.
+		v := (math.Exp(-math.Cos(2*math.Pi*phase)) - 1/math.E) / (math.E - 1/math.E)
+		return e.Intensity * v
+	case EffectBlink:
+		if phase < 0.5 {
+			return e.Intensity
+		}
+	case EffectBlip:
+		if phase < 0.1 {
+			return e.Intensity
+		}
+	case EffectSolid:
+		return e.Intensity
+	case EffectFade:
+		if e.Duration <= 0 || t >= e.Duration {
+			return 0
+		}
+		return e.Intensity * (1 - e.Easing.ease(float64(t)/float64(e.Duration)))
+	case EffectFlash:
+		if slotEvent(seed, t, eventSlot, e.Rate) {
+			return e.Intensity
+		}
+	case EffectFlicker:
+		if slotEvent(seed, t, flickerSlot, e.Rate) {
+			return e.Intensity * (0.3 + 0.4*slotRandom(seed^1, t, flickerSlot))
+		}
+	case EffectSparkle:
+		if slotEvent(seed, t, eventSlot, e.Rate) {
+			return e.Intensity * (0.5 + 0.5*slotRandom(seed^1, t, eventSlot))
+		}
+	case EffectTwinkle:
+		// A twinkle started in any of the last three slots may still be held.
+		for back := time.Duration(0); back < 3; back++ {
+			start := t - back*eventSlot
+			if start < 0 {
+				break
+			}
+			if !slotEvent(seed, start, eventSlot, e.Rate) {
+				continue
+			}
+			hold := 1 + time.Duration(slotRandom(seed^2, start, eventSlot)*3)
+			if back < hold {
+				return e.Intensity * (0.5 + 0.5*slotRandom(seed^1, start, eventSlot))
+			}
+		}
+	}
+	return 0
+}
+
+// apply returns base layered with the effect at elapsed time t.
+func (e Effect) apply(base color.RGBA, t time.Duration, seed uint64) color.RGBA {
+	return BlendColors(base, e.Color, e.level(t, seed))
+}
+
+// slotEvent reports whether an event starts in the slot containing t, given an
+// average of rate events per minute.
+func slotEvent(seed uint64, t, slot time.Duration, rate float64) bool {
+	return slotRandom(seed, t, slot) < rate*slot.Minutes()
+}
+
+// slotRandom returns a pseudo-random number in [0, 1) fixed for the slot containing t.
+func slotRandom(seed uint64, t, slot time.Duration) float64 {
+	return float64(splitmix64(seed^uint64(t/slot))>>11) / (1 << 53)
+}
+
+// splitmix64 is a small, well-mixed hash used to derive per-slot randomness.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// BlendColors linearly interpolates between a and b; t is clamped to [0, 1].
+func BlendColors(a, b color.RGBA, t float64) color.RGBA {
+	if t <= 0 {
+		return a
+	}
+	if t >= 1 {
+		return b
+	}
+	lerp := func(x, y uint8) uint8 {
+		return uint8(float64(x) + t*(float64(y)-float64(x)))
+	}
+	return color.RGBA{
+		R: lerp(a.R, b.R),
+		G: lerp(a.G, b.G),
+		B: lerp(a.B, b.B),
+		A: 0xff,
+	}
+}
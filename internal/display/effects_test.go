@@ -0,0 +1,193 @@
+package display
+
+import (
+	"image/color"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+var white = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+
+func TestEffectApply_Flash(t *testing.T) {
+	base := color.RGBA{G: 200, A: 0xff}
+
+	// One event per slot guarantees a flash in every slot.
+	e := Effect{Kind: EffectFlash, Color: white, Rate: float64(time.Minute / eventSlot), Intensity: 1}
+	if got := e.apply(base, 0, 1); got != white {
+		t.Errorf("flash: got %v, want %v", got, white)
+	}
+}
+
+func TestEffectApply_ZeroRate(t *testing.T) {
+	base := color.RGBA{R: 10, G: 20, B: 30, A: 0xff}
+	for _, kind := range []EffectKind{EffectFlash, EffectFlicker, EffectTwinkle, EffectSparkle} {
+		e := Effect{Kind: kind, Color: white, Intensity: 1}
+		for i := 0; i < 100; i++ {
+			if got := e.apply(base, time.Duration(i)*eventSlot, 1); got != base {
+				t.Fatalf("kind %v slot %d: got %v, want base %v", kind, i, got, base)
+			}
+		}
+	}
+}
+
+func TestEffectApply_RandomRate(t *testing.T) {
+	// Over an hour of slots, 12 flashes per minute should average out close to 720.
+	e := Effect{Kind: EffectFlash, Color: white, Rate: 12, Intensity: 1}
+	events := 0
+	for t0 := time.Duration(0); t0 < time.Hour; t0 += eventSlot {
+		if e.level(t0, 42) > 0 {
+			events++
+		}
+	}
+	if events < 600 || events > 840 {
+		t.Errorf("got %d flashes in an hour, want about 720", events)
+	}
+}
+
+func TestEffectApply_Deterministic(t *testing.T) {
+	e := Effect{Kind: EffectTwinkle, Color: white, Rate: 60, Intensity: 1}
+	for i := 0; i < 200; i++ {
+		at := time.Duration(i) * 37 * time.Millisecond
+		if e.level(at, 7) != e.level(at, 7) {
+			t.Fatalf("level at %v differs between calls", at)
+		}
+	}
+}
+
+func TestEffectApply_FlickerIsSofter(t *testing.T) {
+	base := color.RGBA{A: 0xff}
+	e := Effect{Kind: EffectFlicker, Color: white, Rate: float64(time.Minute / flickerSlot), Intensity: 1}
+	got := e.apply(base, 0, 1)
+	if got.R == 0 || got.R > 0xff*7/10+1 {
+		t.Errorf("flicker peak R=%d, want within (0, 70%%]", got.R)
+	}
+}
+
+func TestEffectApply_Pulse(t *testing.T) {
+	base := color.RGBA{A: 0xff}
+	accent := color.RGBA{R: 200, A: 0xff}
+
+	// One pulse per minute: trough at t=0, peak at 30s.
+	e := Effect{Kind: EffectPulse, Color: accent, Rate: 1, Intensity: 1}
+	if got := e.apply(base, 0, 0); got != base {
+		t.Errorf("trough: got %v, want %v", got, base)
+	}
+	if got := e.apply(base, 30*time.Second, 0); got != accent {
+		t.Errorf("peak: got %v, want %v", got, accent)
+	}
+}
+
+func TestEffectApply_Breathe(t *testing.T) {
+	e := Effect{Kind: EffectBreathe, Rate: 1, Intensity: 1}
+	if got := e.level(0, 0); got > 0.001 {
+		t.Errorf("bottom: got %v, want 0", got)
+	}
+	if got := e.level(30*time.Second, 0); got < 0.999 {
+		t.Errorf("peak: got %v, want 1", got)
+	}
+	// Breathing lingers low: a quarter cycle in it is still below a plain pulse.
+	pulse := Effect{Kind: EffectPulse, Rate: 1, Intensity: 1}
+	if e.level(15*time.Second, 0) >= pulse.level(15*time.Second, 0) {
+		t.Error("expected breathe to rise more slowly than pulse")
+	}
+}
+
+func TestEffectApply_Blink(t *testing.T) {
+	base := color.RGBA{A: 0xff}
+
+	// Six blinks per minute: on for the first 5s of every 10s.
+	e := Effect{Kind: EffectBlink, Color: white, Rate: 6, Intensity: 1}
+	if got := e.apply(base, 2*time.Second, 0); got != white {
+		t.Errorf("on phase: got %v, want %v", got, white)
+	}
+	if got := e.apply(base, 7*time.Second, 0); got != base {
+		t.Errorf("off phase: got %v, want %v", got, base)
+	}
+}
+
+func TestEffectApply_Blip(t *testing.T) {
+	base := color.RGBA{G: 200, A: 0xff}
+	black := color.RGBA{A: 0xff}
+
+	// Six blips per minute: dark for the first second of every 10s.
+	e := Effect{Kind: EffectBlip, Color: black, Rate: 6, Intensity: 1}
+	if got := e.apply(base, 500*time.Millisecond, 0); got != black {
+		t.Errorf("blip: got %v, want %v", got, black)
+	}
+	if got := e.apply(base, 3*time.Second, 0); got != base {
+		t.Errorf("between blips: got %v, want %v", got, base)
+	}
+}
+
+func TestEffectApply_Fade(t *testing.T) {
+	base := color.RGBA{A: 0xff}
+	e := Effect{Kind: EffectFade, Color: color.RGBA{R: 200, A: 0xff}, Intensity: 1, Duration: time.Second}
+
+	if got := e.apply(base, 0, 0); got.R != 200 {
+		t.Errorf("start: got %v, want effect color", got)
+	}
+	if got := e.apply(base, 500*time.Millisecond, 0); got.R != 100 {
+		t.Errorf("halfway: got %v, want R=100", got)
+	}
+	if got := e.apply(base, time.Second, 0); got != base {
+		t.Errorf("end: got %v, want base", got)
+	}
+}
+
+func TestEffectApply_Solid(t *testing.T) {
+	base := color.RGBA{A: 0xff}
+	e := Effect{Kind: EffectSolid, Color: white, Intensity: 1}
+	for _, at := range []time.Duration{0, time.Second, time.Hour} {
+		if got := e.apply(base, at, 0); got != white {
+			t.Errorf("at %v: got %v, want %v", at, got, white)
+		}
+	}
+}
+
+func TestEffectActive(t *testing.T) {
+	now := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+
+	if !(Effect{}).active(now, true) {
+		t.Error("effect without limits should be active")
+	}
+	if (Effect{NightOff: true}).active(now, true) {
+		t.Error("NightOff effect should be inactive at night")
+	}
+	if (Effect{Until: now}).active(now, false) {
+		t.Error("effect should be inactive once Until is reached")
+	}
+	if !(Effect{Until: now.Add(time.Minute)}).active(now, false) {
+		t.Error("effect should be active before Until")
+	}
+}
+
+func TestParseEffectKind(t *testing.T) {
+	k, err := ParseEffectKind("Twinkle")
+	if err != nil || k != EffectTwinkle {
+		t.Errorf("got %v, %v; want EffectTwinkle", k, err)
+	}
+	if _, err := ParseEffectKind("strobe"); err == nil {
+		t.Error("expected error for unknown effect")
+	}
+}
+
+func TestEffectNames_MatchConfig(t *testing.T) {
+	if got := slices.Sorted(maps.Keys(effectNames)); !slices.Equal(got, slices.Sorted(slices.Values(config.Effects))) {
+		t.Errorf("effect names %v differ from config.Effects %v", got, config.Effects)
+	}
+}
+
+func TestBlendColorsClamp(t *testing.T) {
+	a := color.RGBA{R: 10, A: 0xff}
+	b := color.RGBA{R: 200, A: 0xff}
+	if got := BlendColors(a, b, -1); got != a {
+		t.Errorf("t<0: got %v, want %v", got, a)
+	}
+	if got := BlendColors(a, b, 2); got != b {
+		t.Errorf("t>1: got %v, want %v", got, b)
+	}
+}
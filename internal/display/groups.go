@@ -0,0 +1,37 @@
+package display
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+// groupFrame returns a partial frame lighting each configured group with its
+// color and optional effect. Groups are submitted once at startup; stations and
+// legend LEDs never share their LEDs, so they are left alone afterwards.
+func groupFrame(groups map[string]config.GroupConfig) (Frame, error) {
+	var frame Frame
+	for _, name := range slices.Sorted(maps.Keys(groups)) {
+		g := groups[name]
+		col, err := config.ParseHexColor(g.Color)
+		if err != nil {
+			return Frame{}, fmt.Errorf("group %q: %w", name, err)
+		}
+
+		var effects []Effect
+		if g.Effect.Effect != "" {
+			e, err := EffectFromConfig(g.Effect)
+			if err != nil {
+				return Frame{}, fmt.Errorf("group %q: %w", name, err)
+			}
+			effects = append(effects, e)
+		}
+
+		for _, num := range g.LedNumbers() {
+			frame.Pixels = append(frame.Pixels, Pixel{Num: num, Color: col, Effects: effects})
+		}
+	}
+	return frame, nil
+}
@@ -0,0 +1,55 @@
+package display
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+func TestGroupFrame(t *testing.T) {
+	frame, err := groupFrame(map[string]config.GroupConfig{
+		"vors":  {Leds: []int{4, 9}, Color: "#00ffff"},
+		"route": {Ranges: [][]int{{20, 22}}, Color: "#ff00ff", Effect: config.EffectConfig{Effect: "pulse", Color: "#ffffff", Rate: 6, Intensity: 0.3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cyan := color.RGBA{G: 0xff, B: 0xff, A: 0xff}
+	magenta := color.RGBA{R: 0xff, B: 0xff, A: 0xff}
+	want := []struct {
+		num     int
+		col     color.RGBA
+		effects int
+	}{
+		{20, magenta, 1}, {21, magenta, 1}, {22, magenta, 1},
+		{4, cyan, 0}, {9, cyan, 0},
+	}
+	if len(frame.Pixels) != len(want) {
+		t.Fatalf("got %d pixels, want %d: %+v", len(frame.Pixels), len(want), frame.Pixels)
+	}
+	for i, w := range want {
+		p := frame.Pixels[i]
+		if p.Num != w.num || p.Color != w.col || len(p.Effects) != w.effects {
+			t.Errorf("pixel %d: got %+v, want LED %d %v with %d effects", i, p, w.num, w.col, w.effects)
+		}
+	}
+	if e := frame.Pixels[0].Effects[0]; e.Kind != EffectPulse || e.Rate != 6 {
+		t.Errorf("route effect: got %+v", e)
+	}
+	if frame.Complete {
+		t.Error("group frame should be partial so it leaves stations alone")
+	}
+}
+
+func TestGroupFrame_Invalid(t *testing.T) {
+	for name, g := range map[string]config.GroupConfig{
+		"color":  {Leds: []int{1}, Color: "cyan"},
+		"effect": {Leds: []int{1}, Color: "#00ffff", Effect: config.EffectConfig{Effect: "strobe", Color: "#ffffff"}},
+	} {
+		if _, err := groupFrame(map[string]config.GroupConfig{name: g}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package display
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+// StripTypes lists the supported strip_type color orders. Types ending in w are
+// RGBW strips such as the SK6812.
+var StripTypes = []string{
+	"rgb", "rbg", "grb", "gbr", "brg", "bgr",
+	"rgbw", "rbgw", "grbw", "gbrw", "brgw", "bgrw",
+}
+
+// isRGBW reports whether a strip_type has a white die.
+func isRGBW(stripType string) bool {
+	return strings.HasSuffix(stripType, "w")
+}
+
+// GPIO pins (BCM numbering) able to drive each ws281x channel. PCM and SPI can
+// only drive a single channel, so a second channel requires PWM on both.
+var (
+	pwm0Pins = []int{12, 18, 40, 52}
+	pwm1Pins = []int{13, 19, 41, 45, 53}
+	pcmPins  = []int{21, 31}
+	spiPins  = []int{10, 38}
+)
+
+// ValidateHardware reports unsupported combinations of ws281x options.
+func ValidateHardware(hw config.HardwareConfig) error {
+	var errs []error
+
+	if hw.Frequency != 800000 && hw.Frequency != 400000 {
+		errs = append(errs, fmt.Errorf("frequency %d Hz not supported, use 800000 or 400000", hw.Frequency))
+	}
+
+	switch {
+	case hw.DMA < 1 || hw.DMA > 14:
+		errs = append(errs, fmt.Errorf("dma %d out of range 1-14", hw.DMA))
+	case hw.DMA == 5:
+		errs = append(errs, errors.New("dma 5 is used by the SD card and can corrupt the filesystem, use 10"))
+	}
+
+	switch len(hw.Channels) {
+	case 0:
+		errs = append(errs, errors.New("at least one channel is required"))
+	case 1, 2:
+	default:
+		errs = append(errs, fmt.Errorf("%d channels configured, at most 2 are supported", len(hw.Channels)))
+	}
+
+	for i, ch := range hw.Channels {
+		if !slices.Contains(StripTypes, ch.StripType) {
+			errs = append(errs, fmt.Errorf("channel %d: strip_type %q not one of %v", i, ch.StripType, StripTypes))
+		}
+		if isRGBW(ch.StripType) {
+			if _, err := ParseWhiteExtraction(ch.White); err != nil {
+				errs = append(errs, fmt.Errorf("channel %d: %w", i, err))
+			}
+		}
+		if ch.LedCount <= 0 {
+			errs = append(errs, fmt.Errorf("channel %d: led_count must be positive", i))
+		}
+	}
+
+	if len(hw.Channels) > 0 {
+		pin := hw.Channels[0].GpioPin
+		usable := slices.Concat(pwm0Pins, pcmPins, spiPins)
+		if !slices.Contains(usable, pin) {
+			errs = append(errs, fmt.Errorf("channel 0: gpio_pin %d cannot drive LEDs, use one of %v", pin, usable))
+		}
+		if len(hw.Channels) > 1 && !slices.Contains(pwm0Pins, pin) {
+			errs = append(errs, fmt.Errorf("channel 0: gpio_pin %d is not PWM0 (%v), required when two channels are used", pin, pwm0Pins))
+		}
+	}
+	if len(hw.Channels) > 1 {
+		if pin := hw.Channels[1].GpioPin; !slices.Contains(pwm1Pins, pin) {
+			errs = append(errs, fmt.Errorf("channel 1: gpio_pin %d is not PWM1, use one of %v", pin, pwm1Pins))
+		}
+	}
+
+	if len(hw.Segments) == 0 {
+		errs = append(errs, errors.New("at least one segment is required"))
+	}
+	errs = append(errs, validateSegments(hw)...)
+
+	return errors.Join(errs...)
+}
+
+// whiteExtractions returns the white extraction for each channel, WhiteOff for
+// RGB strips. The config is assumed to have passed ValidateHardware.
+func whiteExtractions(hw config.HardwareConfig) []WhiteExtraction {
+	white := make([]WhiteExtraction, len(hw.Channels))
+	for i, ch := range hw.Channels {
+		if isRGBW(ch.StripType) {
+			white[i], _ = ParseWhiteExtraction(ch.White)
+		}
+	}
+	return white
+}
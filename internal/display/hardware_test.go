@@ -0,0 +1,111 @@
+package display
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+func validHardware() config.HardwareConfig {
+	return config.HardwareConfig{
+		Frequency: 800000,
+		DMA:       10,
+		Channels:  []config.ChannelConfig{{GpioPin: 12, StripType: "rgb", LedCount: 50}},
+		Segments:  []config.SegmentConfig{{Channel: 0, Count: 50}},
+	}
+}
+
+func TestValidateHardware_Valid(t *testing.T) {
+	if err := ValidateHardware(validHardware()); err != nil {
+		t.Errorf("default hardware: %v", err)
+	}
+
+	hw := validHardware()
+	hw.Channels[0] = config.ChannelConfig{GpioPin: 18, StripType: "grb", LedCount: 50, Invert: true}
+	hw.Channels = append(hw.Channels, config.ChannelConfig{GpioPin: 13, StripType: "grb", LedCount: 30})
+	if err := ValidateHardware(hw); err != nil {
+		t.Errorf("two PWM channels: %v", err)
+	}
+
+	hw = validHardware()
+	hw.Channels[0].GpioPin = 10
+	hw.Frequency = 400000
+	if err := ValidateHardware(hw); err != nil {
+		t.Errorf("single SPI channel at 400kHz: %v", err)
+	}
+
+	hw = validHardware()
+	hw.Channels = append(hw.Channels, config.ChannelConfig{GpioPin: 13, StripType: "rgb", LedCount: 30})
+	hw.Segments = []config.SegmentConfig{
+		{Channel: 0, Count: 25},
+		{Channel: 1, Count: 30, Reverse: true},
+		{Channel: 0, Start: 25, Count: 25},
+	}
+	if err := ValidateHardware(hw); err != nil {
+		t.Errorf("segments across two channels: %v", err)
+	}
+
+	hw = validHardware()
+	hw.Channels[0].StripType = "grbw"
+	hw.Channels[0].White = "add"
+	if err := ValidateHardware(hw); err != nil {
+		t.Errorf("RGBW channel: %v", err)
+	}
+}
+
+func TestValidateHardware_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(hw *config.HardwareConfig)
+		want   string
+	}{
+		{"frequency", func(hw *config.HardwareConfig) { hw.Frequency = 1000000 }, "frequency"},
+		{"dma range", func(hw *config.HardwareConfig) { hw.DMA = 15 }, "dma 15"},
+		{"dma 5", func(hw *config.HardwareConfig) { hw.DMA = 5 }, "SD card"},
+		{"no channels", func(hw *config.HardwareConfig) { hw.Channels = nil }, "at least one channel"},
+		{"strip type", func(hw *config.HardwareConfig) { hw.Channels[0].StripType = "rgbx" }, "strip_type"},
+		{"led count", func(hw *config.HardwareConfig) { hw.Channels[0].LedCount = 0 }, "led_count"},
+		{"white", func(hw *config.HardwareConfig) {
+			hw.Channels[0].StripType = "rgbw"
+			hw.Channels[0].White = "max"
+		}, `unknown white extraction "max"`},
+		{"pin", func(hw *config.HardwareConfig) { hw.Channels[0].GpioPin = 4 }, "gpio_pin 4"},
+		{"second channel pin", func(hw *config.HardwareConfig) {
+			hw.Channels = append(hw.Channels, config.ChannelConfig{GpioPin: 18, StripType: "rgb", LedCount: 10})
+		}, "channel 1: gpio_pin 18"},
+		{"second channel needs PWM", func(hw *config.HardwareConfig) {
+			hw.Channels[0].GpioPin = 21
+			hw.Channels = append(hw.Channels, config.ChannelConfig{GpioPin: 13, StripType: "rgb", LedCount: 10})
+		}, "not PWM0"},
+		{"three channels", func(hw *config.HardwareConfig) {
+			hw.Channels = append(hw.Channels, hw.Channels[0], hw.Channels[0])
+		}, "at most 2"},
+		{"no segments", func(hw *config.HardwareConfig) { hw.Segments = nil }, "at least one segment"},
+		{"segment channel", func(hw *config.HardwareConfig) { hw.Segments[0].Channel = 1 }, "channel 1 not configured"},
+		{"segment count", func(hw *config.HardwareConfig) { hw.Segments[0].Count = 0 }, "count must be positive"},
+		{"segment beyond channel", func(hw *config.HardwareConfig) { hw.Segments[0].Start = 10 }, "LEDs 10-59 beyond the 50"},
+		{"segments overlap", func(hw *config.HardwareConfig) {
+			hw.Segments = []config.SegmentConfig{{Channel: 0, Count: 30}, {Channel: 0, Start: 20, Count: 10}}
+		}, "segment 1: overlaps segment 0 at channel 0 LED 20"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hw := validHardware()
+			tt.modify(&hw)
+			err := ValidateHardware(hw)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpen_InvalidHardware(t *testing.T) {
+	hw := validHardware()
+	hw.Channels[0].GpioPin = 4
+	_, err := Open(config.Config{Backend: config.BackendTerminal, Hardware: hw})
+	if err == nil || !strings.Contains(err.Error(), "gpio_pin 4") {
+		t.Errorf("got %v, want the terminal backend to reject gpio_pin 4", err)
+	}
+}
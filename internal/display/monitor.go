@@ -0,0 +1,163 @@
+package display
+
+import (
+	"image/color"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+// Snapshot is the state of every LED at one moment.
+type Snapshot struct {
+	Time       time.Time
+	Colors     []color.RGBA // before brightness and color correction
+	Brightness []int        // 0-255, from each LED's channel
+}
+
+// Monitor records what the display loop shows so the LEDs can be mirrored
+// elsewhere, such as the browser simulator. It is safe for concurrent use.
+type Monitor struct {
+	mu          sync.Mutex
+	channels    []int // channel of each LED
+	brightness  []int // per channel
+	current     Snapshot
+	power       PowerEstimate
+	subscribers map[chan struct{}]struct{}
+
+	// history holds every snapshot recorded within window, oldest first.
+	window  time.Duration
+	history []Snapshot
+}
+
+// NewMonitor returns a Monitor for the LEDs in hw that keeps the snapshots
+// recorded within the last window for History.
+func NewMonitor(hw config.HardwareConfig, window time.Duration) *Monitor {
+	m := &Monitor{subscribers: make(map[chan struct{}]struct{}), window: window}
+	for _, a := range addressMap(hw.Segments) {
+		m.channels = append(m.channels, a.channel)
+	}
+	for _, ch := range hw.Channels {
+		m.brightness = append(m.brightness, ch.Brightness)
+	}
+	m.current = Snapshot{
+		Colors:     make([]color.RGBA, len(m.channels)),
+		Brightness: m.ledBrightness(),
+	}
+	return m
+}
+
+// Snapshot returns a copy of the LED state last recorded.
+func (m *Monitor) Snapshot() Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Snapshot{
+		Time:       m.current.Time,
+		Colors:     slices.Clone(m.current.Colors),
+		Brightness: slices.Clone(m.current.Brightness),
+	}
+}
+
+// History returns the snapshots recorded since the given time, oldest first,
+// starting with the one on show at since, retimed to since. The returned
+// snapshots' colors and brightness must not be modified.
+func (m *Monitor) History(since time.Time) []Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i, found := slices.BinarySearchFunc(m.history, since, func(s Snapshot, t time.Time) int {
+		return s.Time.Compare(t)
+	})
+	if found || i == 0 {
+		return slices.Clone(m.history[i:])
+	}
+	snaps := slices.Clone(m.history[i-1:])
+	snaps[0].Time = since
+	return snaps
+}
+
+// Subscribe returns a channel signalled whenever the LED state changes, and a
+// function to stop the notifications. Signals are coalesced, so a slow reader
+// only sees the latest state.
+func (m *Monitor) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	m.mu.Lock()
+	m.subscribers[ch] = struct{}{}
+	m.mu.Unlock()
+	return ch, func() {
+		m.mu.Lock()
+		delete(m.subscribers, ch)
+		m.mu.Unlock()
+	}
+}
+
+// record stores the colors shown on the LEDs, notifying subscribers if any changed.
+func (m *Monitor) record(colors []color.RGBA, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.Equal(m.current.Colors, colors) {
+		return
+	}
+	m.current.Colors = slices.Clone(colors)
+	m.changed(now)
+}
+
+// setBrightness stores the brightness of a channel, notifying subscribers if it changed.
+func (m *Monitor) setBrightness(channel, brightness int, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.brightness[channel] == brightness {
+		return
+	}
+	m.brightness[channel] = brightness
+	m.current.Brightness = m.ledBrightness()
+	m.changed(now)
+}
+
+// observe records the brightness and power draw of l after a Render. It must be
+// called from the goroutine that renders l.
+func (m *Monitor) observe(l *Leds, now time.Time) {
+	for ch, b := range l.effective {
+		if ch < len(m.brightness) {
+			m.setBrightness(ch, b, now)
+		}
+	}
+	m.mu.Lock()
+	m.power = l.estimate
+	m.mu.Unlock()
+}
+
+// Power returns the power draw estimated for the last frame rendered.
+func (m *Monitor) Power() PowerEstimate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.power
+}
+
+// changed must be called with m.mu held. The slices in current are replaced
+// rather than modified, so the history can share them.
+func (m *Monitor) changed(now time.Time) {
+	m.current.Time = now
+	if m.window > 0 {
+		cutoff := now.Add(-m.window)
+		drop := 0
+		for drop < len(m.history) && m.history[drop].Time.Before(cutoff) {
+			drop++
+		}
+		m.history = append(m.history[drop:], m.current)
+	}
+	for ch := range m.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (m *Monitor) ledBrightness() []int {
+	b := make([]int, len(m.channels))
+	for i, ch := range m.channels {
+		b[i] = m.brightness[ch]
+	}
+	return b
+}
@@ -0,0 +1,89 @@
+package display
+
+import (
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+func TestMonitor(t *testing.T) {
+	m := NewMonitor(config.HardwareConfig{
+		Channels: []config.ChannelConfig{{LedCount: 2, Brightness: 200}, {LedCount: 1, Brightness: 100}},
+		Segments: []config.SegmentConfig{{Channel: 0, Count: 2}, {Channel: 1, Count: 1}},
+	}, 0)
+	updates, stop := m.Subscribe()
+	defer stop()
+
+	if s := m.Snapshot(); len(s.Colors) != 3 || s.Brightness[1] != 200 || s.Brightness[2] != 100 {
+		t.Fatalf("initial snapshot: %+v", s)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	colors := []color.RGBA{red, green, {}}
+	m.record(colors, now)
+	colors[0] = green // the monitor keeps its own copy
+
+	select {
+	case <-updates:
+	default:
+		t.Fatal("no update after record")
+	}
+	s := m.Snapshot()
+	if s.Colors[0] != red || s.Colors[1] != green || !s.Time.Equal(now) {
+		t.Errorf("snapshot after record: %+v", s)
+	}
+
+	m.record([]color.RGBA{red, green, {}}, now.Add(time.Second))
+	select {
+	case <-updates:
+		t.Error("update sent for unchanged colors")
+	default:
+	}
+
+	m.setBrightness(1, 20, now.Add(2*time.Second))
+	m.setBrightness(1, 10, now.Add(3*time.Second))
+	<-updates
+	select {
+	case <-updates:
+		t.Error("updates not coalesced")
+	default:
+	}
+	if s := m.Snapshot(); s.Brightness[0] != 200 || s.Brightness[2] != 10 {
+		t.Errorf("brightness after setBrightness: %v", s.Brightness)
+	}
+}
+
+func TestMonitorHistory(t *testing.T) {
+	m := NewMonitor(config.HardwareConfig{
+		Channels: []config.ChannelConfig{{LedCount: 1, Brightness: 255}},
+		Segments: []config.SegmentConfig{{Channel: 0, Count: 1}},
+	}, 10*time.Second)
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 15; i++ {
+		m.record([]color.RGBA{{R: uint8(i), A: 0xff}}, start.Add(time.Duration(i)*time.Second))
+	}
+
+	all := m.History(time.Time{})
+	if len(all) != 11 || all[0].Colors[0].R != 4 || all[10].Colors[0].R != 14 {
+		t.Fatalf("history kept %d snapshots from %v to %v, want 11 from R=4 to R=14",
+			len(all), all[0].Colors[0], all[len(all)-1].Colors[0])
+	}
+	recent := m.History(start.Add(12 * time.Second))
+	if len(recent) != 3 || recent[0].Colors[0].R != 12 {
+		t.Errorf("History since 12s: got %d snapshots starting %v", len(recent), recent[0].Colors[0])
+	}
+
+	// Between changes, history starts with the state on show at since.
+	since := start.Add(12*time.Second + 500*time.Millisecond)
+	recent = m.History(since)
+	if len(recent) != 3 || recent[0].Colors[0].R != 12 || !recent[0].Time.Equal(since) || recent[1].Colors[0].R != 13 {
+		t.Errorf("History since 12.5s: got %d snapshots starting %v at %v, want 3 starting R=12 at 12.5s",
+			len(recent), recent[0].Colors[0], recent[0].Time)
+	}
+	if last := m.History(start.Add(time.Minute)); len(last) != 1 || last[0].Colors[0].R != 14 {
+		t.Errorf("History after the last change: got %v, want only the state on show", last)
+	}
+}
@@ -0,0 +1,71 @@
+package display
+
+import (
+	"github.com/finack/twinkle/internal/config"
+)
+
+// PowerModel estimates the current drawn by the strip from the values written
+// to each LED and the channel brightness.
+type PowerModel struct {
+	BudgetMA     float64 // frames are dimmed to stay within this; 0 disables limiting
+	MAPerChannel float64 // draw of one color die at full value
+	IdleMAPerLed float64 // draw of each LED's controller, even when dark
+}
+
+// PowerModelFromConfig builds the power model from config settings.
+func PowerModelFromConfig(c config.PowerConfig) PowerModel {
+	return PowerModel{BudgetMA: c.BudgetMA, MAPerChannel: c.MAPerChannel, IdleMAPerLed: c.IdleMAPerLed}
+}
+
+// PowerEstimate is the estimated draw of one frame.
+type PowerEstimate struct {
+	RequestedMA float64 // at the requested brightness
+	DrawMA      float64 // after limiting
+	BudgetMA    float64 // 0 when limiting is disabled
+	Scale       float64 // brightness factor applied; 1 when within budget
+}
+
+// Limited reports whether the frame was dimmed to stay within budget.
+func (e PowerEstimate) Limited() bool {
+	return e.DrawMA < e.RequestedMA
+}
+
+// limit estimates the draw of strips at the requested brightness per channel
+// and returns the brightness to use so the draw stays within budget. Dimming is
+// proportional across channels; the idle draw can't be dimmed.
+func (p PowerModel) limit(strips [][]uint32, brightness []int) (PowerEstimate, []int) {
+	var idle float64
+	// full holds the draw of each channel at full brightness.
+	full := make([]float64, len(strips))
+	for ch, strip := range strips {
+		idle += float64(len(strip)) * p.IdleMAPerLed
+		var sum uint32
+		for _, word := range strip {
+			sum += word>>24&0xff + word>>16&0xff + word>>8&0xff + word&0xff
+		}
+		full[ch] = float64(sum) / 0xff * p.MAPerChannel
+	}
+	draw := func(brightness []int) float64 {
+		ma := idle
+		for ch, b := range brightness {
+			if ch < len(full) {
+				ma += full[ch] * float64(b) / 0xff
+			}
+		}
+		return ma
+	}
+
+	requested := draw(brightness)
+	est := PowerEstimate{RequestedMA: requested, DrawMA: requested, BudgetMA: p.BudgetMA, Scale: 1}
+	effective := append([]int(nil), brightness...)
+	if p.BudgetMA <= 0 || requested <= p.BudgetMA || requested == idle {
+		return est, effective
+	}
+
+	est.Scale = max(p.BudgetMA-idle, 0) / (requested - idle)
+	for ch, b := range brightness {
+		effective[ch] = int(float64(b) * est.Scale)
+	}
+	est.DrawMA = draw(effective)
+	return est, effective
+}
@@ -0,0 +1,81 @@
+package display
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"golang.org/x/image/colornames"
+)
+
+func TestPowerModelLimit(t *testing.T) {
+	model := PowerModel{MAPerChannel: 20, IdleMAPerLed: 1}
+	white := []uint32{0xffffff, 0xffffff, 0xffffff, 0xffffff} // 4 LEDs, 60mA each at full
+	rgbw := []uint32{0xff000000, 0}                           // white die only
+
+	tests := []struct {
+		name       string
+		budget     float64
+		strips     [][]uint32
+		brightness []int
+		want       PowerEstimate
+		effective  []int
+	}{
+		{"no budget", 0, [][]uint32{white}, []int{255},
+			PowerEstimate{RequestedMA: 244, DrawMA: 244, Scale: 1}, []int{255}},
+		{"within budget", 300, [][]uint32{white, rgbw}, []int{255, 255},
+			PowerEstimate{RequestedMA: 266, DrawMA: 266, BudgetMA: 300, Scale: 1}, []int{255, 255}},
+		{"half brightness", 150, [][]uint32{white}, []int{102},
+			PowerEstimate{RequestedMA: 100, DrawMA: 100, BudgetMA: 150, Scale: 1}, []int{102}},
+		{"over budget", 124, [][]uint32{white, rgbw}, []int{255, 255},
+			PowerEstimate{RequestedMA: 266, DrawMA: 123.25, BudgetMA: 124, Scale: 0.45}, []int{115, 115}},
+		{"budget below idle", 2, [][]uint32{white}, []int{255},
+			PowerEstimate{RequestedMA: 244, DrawMA: 4, BudgetMA: 2, Scale: 0}, []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model.BudgetMA = tt.budget
+			got, effective := model.limit(tt.strips, tt.brightness)
+			round := func(v float64) float64 { return math.Round(v*100) / 100 }
+			if round(got.RequestedMA) != tt.want.RequestedMA || round(got.DrawMA) != tt.want.DrawMA ||
+				got.BudgetMA != tt.want.BudgetMA || round(got.Scale) != tt.want.Scale {
+				t.Errorf("estimate: got %+v, want %+v", got, tt.want)
+			}
+			if !slices.Equal(effective, tt.effective) {
+				t.Errorf("brightness: got %v, want %v", effective, tt.effective)
+			}
+			if got.Limited() != (tt.want.Scale < 1) {
+				t.Errorf("Limited() = %v", got.Limited())
+			}
+		})
+	}
+}
+
+func TestLedsRender_PowerLimit(t *testing.T) {
+	mock := newMock(4)
+	l := newWithEngine(mock)
+	l.SetBrightness(0, 200)
+	l.Power = PowerModel{BudgetMA: 100, MAPerChannel: 20}
+
+	l.Display(0, colornames.Red)
+	if err := l.Render(); err != nil {
+		t.Fatal(err)
+	}
+	if mock.brightness[0] != 200 || l.Estimate().Limited() {
+		t.Errorf("one red LED: brightness %d, estimate %+v", mock.brightness[0], l.Estimate())
+	}
+
+	for i := range 4 {
+		l.Display(i, colornames.White)
+	}
+	l.Render()
+	if mock.brightness[0] != 106 || !l.Estimate().Limited() || l.Estimate().DrawMA > 100 {
+		t.Errorf("four white LEDs: brightness %d, estimate %+v", mock.brightness[0], l.Estimate())
+	}
+
+	l.SetBrightness(0, 50)
+	l.Render()
+	if mock.brightness[0] != 50 || l.Estimate().Limited() {
+		t.Errorf("dimmed: brightness %d, estimate %+v", mock.brightness[0], l.Estimate())
+	}
+}
@@ -0,0 +1,181 @@
+package display
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+// QuietHours dims the display to nothing during scheduled windows, fading out
+// at the start of each and back in at its end. An override holds the display on
+// or off for a while regardless of the schedule. It is safe for concurrent use.
+type QuietHours struct {
+	loc     *time.Location
+	fade    time.Duration
+	windows []quietWindow
+
+	mu       sync.Mutex
+	override *QuietOverride
+}
+
+type quietWindow struct {
+	days  [7]bool       // by time.Weekday
+	start time.Duration // since midnight
+	end   time.Duration // since midnight; at or before start runs into the next day
+}
+
+// QuietOverride holds the display on or off until Until, whatever the schedule.
+type QuietOverride struct {
+	On    bool
+	Until time.Time
+
+	from float64   // level when the override was set, faded from
+	set  time.Time // when the override was set
+}
+
+// QuietStatus is what QuietHours is doing at a moment.
+type QuietStatus struct {
+	Scheduled bool           // within a quiet window
+	Level     float64        // 0 (off) to 1 (on)
+	Override  *QuietOverride // nil unless one is in force
+}
+
+// QuietHoursFromConfig builds the quiet windows from c.QuietHours in c.Locale.
+func QuietHoursFromConfig(c config.Config) (*QuietHours, error) {
+	loc, err := time.LoadLocation(c.Locale)
+	if err != nil {
+		return nil, fmt.Errorf("quiet hours locale %q: %w", c.Locale, err)
+	}
+	q := &QuietHours{loc: loc, fade: time.Duration(c.QuietHours.FadeS * float64(time.Second))}
+	for _, w := range c.QuietHours.Windows {
+		var qw quietWindow
+		if qw.start, err = clockOffset(w.Start); err != nil {
+			return nil, err
+		}
+		if qw.end, err = clockOffset(w.End); err != nil {
+			return nil, err
+		}
+		for _, name := range w.Days {
+			d, ok := config.ParseWeekday(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown quiet day %q", name)
+			}
+			qw.days[d] = true
+		}
+		if len(w.Days) == 0 {
+			qw.days = [7]bool{true, true, true, true, true, true, true}
+		}
+		q.windows = append(q.windows, qw)
+	}
+	return q, nil
+}
+
+// clockOffset converts a time of day such as "22:00" into the time since midnight.
+func clockOffset(s string) (time.Duration, error) {
+	at, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("quiet time %q: %w", s, err)
+	}
+	return time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute, nil
+}
+
+// Level returns how far the display is on at now, from 0 (off) to 1 (on).
+func (q *QuietHours) Level(now time.Time) float64 {
+	return q.Status(now).Level
+}
+
+// Status reports whether now is within a quiet window, the level to show and
+// any override in force.
+func (q *QuietHours) Status(now time.Time) QuietStatus {
+	now = now.In(q.loc)
+	scheduled := q.scheduled(now)
+	status := QuietStatus{Scheduled: scheduled < 1, Level: scheduled}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	o := q.override
+	if o == nil {
+		return status
+	}
+	target := 0.0
+	if o.On {
+		target = 1
+	}
+	if now.Before(o.Until) {
+		status.Level = o.from + (target-o.from)*fadeProgress(now.Sub(o.set), q.fade)
+		override := *o
+		status.Override = &override
+		return status
+	}
+	// Ease from the override back onto the schedule, then forget it.
+	x := fadeProgress(now.Sub(o.Until), q.fade)
+	if x >= 1 {
+		q.override = nil
+		return status
+	}
+	status.Level = target + (scheduled-target)*x
+	return status
+}
+
+// Override holds the display on or off for d from now.
+func (q *QuietHours) Override(on bool, d time.Duration, now time.Time) QuietOverride {
+	from := q.Level(now)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.override = &QuietOverride{On: on, Until: now.Add(d).In(q.loc), from: from, set: now}
+	return *q.override
+}
+
+// ClearOverride returns to the schedule, fading if the levels differ.
+func (q *QuietHours) ClearOverride(now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.override != nil && now.Before(q.override.Until) {
+		q.override.Until = now
+	}
+}
+
+// scheduled returns the level the windows call for at now, the darkest of any
+// that apply.
+func (q *QuietHours) scheduled(now time.Time) float64 {
+	level := 1.0
+	today := midnight(now)
+	for _, w := range q.windows {
+		// A window started yesterday may still be running past midnight.
+		for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+			if !w.days[day.Weekday()] {
+				continue
+			}
+			endDay := day
+			if w.end <= w.start {
+				endDay = day.AddDate(0, 0, 1)
+			}
+			start, end := clockTime(day, w.start), clockTime(endDay, w.end)
+			level = math.Min(level, windowLevel(now, start, end, q.fade))
+		}
+	}
+	return level
+}
+
+// windowLevel fades out from start and back in from end.
+func windowLevel(now, start, end time.Time, fade time.Duration) float64 {
+	if now.Before(start) || !now.Before(end.Add(fade)) {
+		return 1
+	}
+	in := 0.0
+	if !now.Before(end) {
+		in = fadeProgress(now.Sub(end), fade)
+	}
+	return math.Max(1-fadeProgress(now.Sub(start), fade), in)
+}
+
+// fadeProgress returns how far through a fade of length fade elapsed is, from 0 to 1.
+func fadeProgress(elapsed, fade time.Duration) float64 {
+	if fade <= 0 {
+		return 1
+	}
+	return math.Min(math.Max(float64(elapsed)/float64(fade), 0), 1)
+}
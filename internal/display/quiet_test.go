@@ -0,0 +1,117 @@
+package display
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+func newTestQuietHours(t *testing.T, windows ...config.QuietWindow) *QuietHours {
+	t.Helper()
+	q, err := QuietHoursFromConfig(config.Config{
+		Locale:     "America/Los_Angeles",
+		QuietHours: config.QuietHoursConfig{FadeS: 60, Windows: windows},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func TestQuietHours_Schedule(t *testing.T) {
+	q := newTestQuietHours(t,
+		config.QuietWindow{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "19:00", End: "07:00"},
+		config.QuietWindow{Days: []string{"Saturday", "sunday"}, Start: "22:00", End: "09:00"},
+	)
+	loc := q.loc
+	at := func(day, hour, min, sec int) time.Time {
+		// 2024-06-17 is a Monday.
+		return time.Date(2024, 6, 17+day, hour, min, sec, 0, loc)
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want float64
+	}{
+		{"monday afternoon", at(0, 15, 0, 0), 1},
+		{"monday start", at(0, 19, 0, 0), 1},
+		{"fading out", at(0, 19, 0, 30), 0.5},
+		{"monday evening", at(0, 21, 0, 0), 0},
+		{"past midnight", at(1, 3, 0, 0), 0},
+		{"tuesday end", at(1, 7, 0, 0), 0},
+		{"fading in", at(1, 7, 0, 15), 0.25},
+		{"tuesday morning", at(1, 8, 0, 0), 1},
+		{"friday night into saturday", at(5, 6, 0, 0), 0},
+		{"saturday evening", at(5, 20, 0, 0), 1},
+		{"saturday night", at(5, 23, 0, 0), 0},
+		{"sunday night into monday", at(7, 8, 0, 0), 0},
+		{"monday after the weekend window", at(7, 9, 30, 0), 1},
+	}
+	for _, tt := range tests {
+		if got := q.Level(tt.now); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s (%s): got %v, want %v", tt.name, tt.now.Format("Mon 15:04:05"), got, tt.want)
+		}
+	}
+}
+
+func TestQuietHours_SameDayWindow(t *testing.T) {
+	q := newTestQuietHours(t, config.QuietWindow{Start: "12:00", End: "13:00"})
+	day := time.Date(2024, 6, 17, 0, 0, 0, 0, q.loc)
+	if got := q.Level(day.Add(12*time.Hour + 30*time.Minute)); got != 0 {
+		t.Errorf("lunch: got %v, want 0", got)
+	}
+	if got := q.Level(day.Add(23 * time.Hour)); got != 1 {
+		t.Errorf("evening: got %v, want 1", got)
+	}
+	if s := q.Status(day.Add(12*time.Hour + 30*time.Minute)); !s.Scheduled {
+		t.Errorf("lunch status: got %+v, want scheduled", s)
+	}
+}
+
+func TestQuietHours_Override(t *testing.T) {
+	q := newTestQuietHours(t, config.QuietWindow{Start: "19:00", End: "07:00"})
+	night := time.Date(2024, 6, 17, 22, 0, 0, 0, q.loc)
+
+	o := q.Override(true, time.Hour, night)
+	if !o.On || !o.Until.Equal(night.Add(time.Hour)) {
+		t.Fatalf("override: got %+v", o)
+	}
+	if got := q.Level(night.Add(30 * time.Second)); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("fading in: got %v, want 0.5", got)
+	}
+	s := q.Status(night.Add(30 * time.Minute))
+	if !s.Scheduled || s.Level != 1 || s.Override == nil || !s.Override.On {
+		t.Errorf("during the override: got %+v", s)
+	}
+	if got := q.Level(night.Add(time.Hour + 30*time.Second)); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("fading back to the schedule: got %v, want 0.5", got)
+	}
+	s = q.Status(night.Add(2 * time.Hour))
+	if s.Level != 0 || s.Override != nil {
+		t.Errorf("after the override: got %+v", s)
+	}
+
+	afternoon := night.Add(-6 * time.Hour)
+	q.Override(false, time.Hour, afternoon)
+	if got := q.Level(afternoon.Add(30 * time.Minute)); got != 0 {
+		t.Errorf("off override: got %v, want 0", got)
+	}
+	q.ClearOverride(afternoon.Add(30 * time.Minute))
+	if s := q.Status(afternoon.Add(time.Hour)); s.Level != 1 || s.Override != nil {
+		t.Errorf("cleared override: got %+v", s)
+	}
+}
+
+func TestQuietHoursFromConfig_Invalid(t *testing.T) {
+	for _, qc := range []config.QuietHoursConfig{
+		{Windows: []config.QuietWindow{{Start: "10pm", End: "07:00"}}},
+		{Windows: []config.QuietWindow{{Days: []string{"someday"}, Start: "22:00", End: "07:00"}}},
+	} {
+		if _, err := QuietHoursFromConfig(config.Config{Locale: "UTC", QuietHours: qc}); err == nil {
+			t.Errorf("%+v: expected an error", qc)
+		}
+	}
+}
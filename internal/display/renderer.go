@@ -0,0 +1,114 @@
+package display
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Frame is a set of pixels submitted to the Renderer together. A partial frame
+// only updates the LEDs it contains; a complete frame turns every other LED off.
+type Frame struct {
+	Pixels   []Pixel
+	Complete bool
+}
+
+// equal reports whether two pixels show the same color and effects.
+func (p Pixel) equal(o Pixel) bool {
+	return p.Color == o.Color && p.QuietOn == o.QuietOn && slices.Equal(p.Effects, o.Effects)
+}
+
+// Renderer accepts frames from any goroutine without blocking and coalesces them
+// until the display loop takes the pending changes.
+type Renderer struct {
+	ledCount int
+
+	mu        sync.Mutex
+	pending   map[int]Pixel
+	heartbeat time.Time // when the display loop last came round
+	changed   chan struct{}
+}
+
+func NewRenderer(ledCount int) *Renderer {
+	return &Renderer{
+		ledCount: ledCount,
+		pending:  make(map[int]Pixel),
+		changed:  make(chan struct{}, 1),
+	}
+}
+
+// Submit queues a frame for the display loop. Pixels outside the strip are
+// dropped and reported in the returned error; the rest are still applied.
+func (r *Renderer) Submit(f Frame) error {
+	var outOfRange []int
+
+	r.mu.Lock()
+	if f.Complete {
+		for i := 0; i < r.ledCount; i++ {
+			r.pending[i] = Pixel{Num: i}
+		}
+	}
+	for _, p := range f.Pixels {
+		if p.Num < 0 || p.Num >= r.ledCount {
+			outOfRange = append(outOfRange, p.Num)
+			continue
+		}
+		r.pending[p.Num] = p
+	}
+	r.mu.Unlock()
+
+	select {
+	case r.changed <- struct{}{}:
+	default:
+	}
+
+	if len(outOfRange) > 0 {
+		return fmt.Errorf("LEDs %v outside strip of %d", outOfRange, r.ledCount)
+	}
+	return nil
+}
+
+// Changed is signalled after a Submit; call take to collect the pixels.
+func (r *Renderer) Changed() <-chan struct{} {
+	return r.changed
+}
+
+// Heartbeat returns when the display loop last came round, at least once a
+// second while it runs, or the zero time before it starts.
+func (r *Renderer) Heartbeat() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.heartbeat
+}
+
+// beat records that the display loop is running at now.
+func (r *Renderer) beat(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.heartbeat = now
+}
+
+// take returns the pixels submitted since the last call and clears them.
+func (r *Renderer) take() map[int]Pixel {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pending := r.pending
+	r.pending = make(map[int]Pixel)
+	return pending
+}
+
+// diff applies pending pixels to frame and returns the LED numbers whose color or
+// effects changed, in ascending order.
+func diff(frame []Pixel, pending map[int]Pixel) []int {
+	var dirty []int
+	for num, p := range pending {
+		if frame[num].equal(p) {
+			continue
+		}
+		frame[num] = p
+		dirty = append(dirty, num)
+	}
+	slices.Sort(dirty)
+	return dirty
+}
@@ -0,0 +1,97 @@
+package display
+
+import (
+	"image/color"
+	"slices"
+	"testing"
+	"time"
+)
+
+var (
+	red   = color.RGBA{R: 0xff, A: 0xff}
+	green = color.RGBA{G: 0xff, A: 0xff}
+)
+
+func TestRendererSubmit_Partial(t *testing.T) {
+	r := NewRenderer(4)
+	if err := r.Submit(Frame{Pixels: []Pixel{{Num: 1, Color: red}, {Num: 3, Color: green}}}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	select {
+	case <-r.Changed():
+	default:
+		t.Fatal("expected Changed to be signalled")
+	}
+
+	pending := r.take()
+	if len(pending) != 2 || pending[1].Color != red || pending[3].Color != green {
+		t.Errorf("pending: got %+v", pending)
+	}
+	if len(r.take()) != 0 {
+		t.Error("expected take to clear pending pixels")
+	}
+}
+
+func TestRendererSubmit_Complete(t *testing.T) {
+	r := NewRenderer(3)
+	r.Submit(Frame{Pixels: []Pixel{{Num: 2, Color: red}}, Complete: true})
+
+	pending := r.take()
+	if len(pending) != 3 {
+		t.Fatalf("pending: got %d pixels, want 3", len(pending))
+	}
+	if pending[0].Color != (color.RGBA{}) || pending[2].Color != red {
+		t.Errorf("pending: got %+v", pending)
+	}
+}
+
+func TestRendererSubmit_Coalesces(t *testing.T) {
+	r := NewRenderer(2)
+	r.Submit(Frame{Pixels: []Pixel{{Num: 0, Color: red}}})
+	r.Submit(Frame{Pixels: []Pixel{{Num: 0, Color: green}}})
+
+	if pending := r.take(); len(pending) != 1 || pending[0].Color != green {
+		t.Errorf("pending: got %+v, want only the latest color", pending)
+	}
+}
+
+func TestRendererSubmit_OutOfRange(t *testing.T) {
+	r := NewRenderer(2)
+	err := r.Submit(Frame{Pixels: []Pixel{{Num: -1}, {Num: 1, Color: red}, {Num: 2}}})
+	if err == nil {
+		t.Fatal("expected error for LEDs outside the strip")
+	}
+	if pending := r.take(); len(pending) != 1 || pending[1].Color != red {
+		t.Errorf("pending: got %+v, want in-range pixel kept", pending)
+	}
+}
+
+func TestRendererHeartbeat(t *testing.T) {
+	r := NewRenderer(1)
+	if !r.Heartbeat().IsZero() {
+		t.Errorf("before the loop starts: got %v, want the zero time", r.Heartbeat())
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	r.beat(now)
+	if got := r.Heartbeat(); !got.Equal(now) {
+		t.Errorf("got %v, want %v", got, now)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	frame := []Pixel{{Num: 0, Color: red}, {Num: 1, Color: red}, {Num: 2}}
+	flash := []Effect{{Kind: EffectFlash, Rate: 1}}
+
+	dirty := diff(frame, map[int]Pixel{
+		0: {Num: 0, Color: red},     // unchanged
+		1: {Num: 1, Color: green},   // new color
+		2: {Num: 2, Effects: flash}, // new effect
+	})
+	if !slices.Equal(dirty, []int{1, 2}) {
+		t.Errorf("dirty: got %v, want [1 2]", dirty)
+	}
+	if frame[1].Color != green || len(frame[2].Effects) != 1 {
+		t.Errorf("frame not updated: %+v", frame)
+	}
+}
@@ -0,0 +1,64 @@
+package display
+
+import (
+	"fmt"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+// address locates an LED on a ws281x channel.
+type address struct {
+	channel int
+	index   int
+}
+
+// addressMap numbers the LEDs of each segment consecutively, in the order the
+// segments are listed, giving the global LED numbering used by config leds.
+func addressMap(segments []config.SegmentConfig) []address {
+	var addrs []address
+	for _, seg := range segments {
+		for i := 0; i < seg.Count; i++ {
+			idx := seg.Start + i
+			if seg.Reverse {
+				idx = seg.Start + seg.Count - 1 - i
+			}
+			addrs = append(addrs, address{channel: seg.Channel, index: idx})
+		}
+	}
+	return addrs
+}
+
+// validateSegments reports segments that fall outside their channel or overlap
+// another segment.
+func validateSegments(hw config.HardwareConfig) []error {
+	var errs []error
+	used := make([][]int, len(hw.Channels))
+	for i, ch := range hw.Channels {
+		used[i] = make([]int, max(ch.LedCount, 0))
+	}
+
+	for i, seg := range hw.Segments {
+		if seg.Channel < 0 || seg.Channel >= len(hw.Channels) {
+			errs = append(errs, fmt.Errorf("segment %d: channel %d not configured", i, seg.Channel))
+			continue
+		}
+		if seg.Count <= 0 || seg.Start < 0 {
+			errs = append(errs, fmt.Errorf("segment %d: start must not be negative and count must be positive", i))
+			continue
+		}
+		if seg.Start+seg.Count > len(used[seg.Channel]) {
+			errs = append(errs, fmt.Errorf("segment %d: LEDs %d-%d beyond the %d on channel %d",
+				i, seg.Start, seg.Start+seg.Count-1, len(used[seg.Channel]), seg.Channel))
+			continue
+		}
+		for idx := seg.Start; idx < seg.Start+seg.Count; idx++ {
+			if other := used[seg.Channel][idx]; other != 0 {
+				errs = append(errs, fmt.Errorf("segment %d: overlaps segment %d at channel %d LED %d",
+					i, other-1, seg.Channel, idx))
+				break
+			}
+			used[seg.Channel][idx] = i + 1
+		}
+	}
+	return errs
+}
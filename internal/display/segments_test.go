@@ -0,0 +1,50 @@
+package display
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+func TestAddressMap(t *testing.T) {
+	got := addressMap([]config.SegmentConfig{
+		{Channel: 0, Count: 2},
+		{Channel: 1, Start: 1, Count: 3, Reverse: true},
+		{Channel: 0, Start: 2, Count: 1},
+	})
+	want := []address{
+		{0, 0}, {0, 1},
+		{1, 3}, {1, 2}, {1, 1},
+		{0, 2},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("addressMap = %v, want %v", got, want)
+	}
+}
+
+func TestLedsDisplay_Segments(t *testing.T) {
+	mock := newTwoChannelMock(3, 2)
+	l := newWithEngine(mock)
+	l.addresses = addressMap([]config.SegmentConfig{
+		{Channel: 0, Count: 3},
+		{Channel: 1, Count: 2, Reverse: true},
+	})
+
+	l.Display(1, red)
+	l.Display(3, green)
+
+	if mock.leds[1] != ParseRGBAtoUint32(red) {
+		t.Errorf("channel 0 leds[1] = %#x, want red", mock.leds[1])
+	}
+	if mock.channel1[1] != ParseRGBAtoUint32(green) || mock.channel1[0] != 0 {
+		t.Errorf("channel 1 = %#x, want green at index 1 only", mock.channel1)
+	}
+
+	if err := l.Clear(); err != nil {
+		t.Fatalf("Clear() error: %v", err)
+	}
+	if slices.ContainsFunc(slices.Concat(mock.leds, mock.channel1), func(v uint32) bool { return v != 0 }) {
+		t.Errorf("LEDs not cleared on both channels: %#x %#x", mock.leds, mock.channel1)
+	}
+}
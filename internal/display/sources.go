@@ -0,0 +1,191 @@
+package display
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+// BrightnessSource reports how bright the map should be at now, from 0 (night
+// brightness) to 1 (day brightness).
+type BrightnessSource interface {
+	Level(now time.Time) (float64, error)
+}
+
+// illuminanceSource reads an ambient light sensor exposed through the Linux IIO
+// subsystem, such as a TSL2561 or BH1750, and maps its lux reading onto a level
+// on a logarithmic scale, which follows how bright a room looks.
+type illuminanceSource struct {
+	path      string
+	scale     float64
+	darkLux   float64
+	brightLux float64
+}
+
+func (s illuminanceSource) Level(time.Time) (float64, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("illuminance from %s: %w", s.path, err)
+	}
+	lux := v * s.scale
+	if lux <= s.darkLux {
+		return 0, nil
+	}
+	level := math.Log(lux/s.darkLux) / math.Log(s.brightLux/s.darkLux)
+	return math.Min(level, 1), nil
+}
+
+// scheduleSource holds each level from its time of day until the next entry.
+type scheduleSource struct {
+	entries []scheduleEntry // sorted by offset
+}
+
+type scheduleEntry struct {
+	offset time.Duration // since midnight
+	level  float64
+}
+
+func newScheduleSource(entries []config.ScheduleEntry) (*scheduleSource, error) {
+	s := &scheduleSource{}
+	for _, e := range entries {
+		at, err := time.Parse("15:04", e.At)
+		if err != nil {
+			return nil, fmt.Errorf("schedule time %q: %w", e.At, err)
+		}
+		offset := time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute
+		s.entries = append(s.entries, scheduleEntry{offset: offset, level: e.Level})
+	}
+	if len(s.entries) == 0 {
+		return nil, errors.New("schedule has no entries")
+	}
+	slices.SortFunc(s.entries, func(a, b scheduleEntry) int { return int(a.offset - b.offset) })
+	return s, nil
+}
+
+// Level returns the level of the latest entry at or before now, wrapping to the
+// last entry of the previous day before the first one.
+func (s *scheduleSource) Level(now time.Time) (float64, error) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	offset := now.Sub(midnight)
+
+	level := s.entries[len(s.entries)-1].level
+	for _, e := range s.entries {
+		if e.offset > offset {
+			break
+		}
+		level = e.level
+	}
+	return level, nil
+}
+
+// Brightness combines brightness sources into one level, smoothed with an
+// exponential moving average and held until it moves by more than the
+// hysteresis, so a flickering sensor doesn't pump the LEDs.
+type Brightness struct {
+	sources    []BrightnessSource
+	combine    func([]float64) float64
+	smoothing  time.Duration
+	hysteresis float64
+
+	smoothed float64
+	applied  float64
+	updated  time.Time // zero until the first reading
+}
+
+var brightnessCombines = map[string]func([]float64) float64{
+	"min": func(l []float64) float64 { return slices.Min(l) },
+	"max": func(l []float64) float64 { return slices.Max(l) },
+	"average": func(l []float64) float64 {
+		sum := 0.0
+		for _, v := range l {
+			sum += v
+		}
+		return sum / float64(len(l))
+	},
+}
+
+// BrightnessFromConfig builds the configured sources; sun sources share sun.
+func BrightnessFromConfig(bc config.BrightnessConfig, sun *SunCurve) (*Brightness, error) {
+	combine, ok := brightnessCombines[bc.Combine]
+	if !ok {
+		return nil, fmt.Errorf("unknown brightness combine %q", bc.Combine)
+	}
+	b := &Brightness{
+		combine:    combine,
+		smoothing:  time.Duration(bc.SmoothingS * float64(time.Second)),
+		hysteresis: bc.Hysteresis,
+	}
+	for _, src := range bc.Sources {
+		switch src.Type {
+		case config.BrightnessSun:
+			b.sources = append(b.sources, sun)
+		case config.BrightnessIlluminance:
+			b.sources = append(b.sources, illuminanceSource{
+				path:      src.Path,
+				scale:     src.Scale,
+				darkLux:   src.DarkLux,
+				brightLux: src.BrightLux,
+			})
+		case config.BrightnessSchedule:
+			s, err := newScheduleSource(src.Schedule)
+			if err != nil {
+				return nil, err
+			}
+			b.sources = append(b.sources, s)
+		default:
+			return nil, fmt.Errorf("unknown brightness source %q", src.Type)
+		}
+	}
+	return b, nil
+}
+
+// Update reads the sources at now and returns the level to show. Sources that
+// fail are left out and their errors returned; ok is false if none could be
+// read, and the level should be left alone.
+func (b *Brightness) Update(now time.Time) (level float64, ok bool, err error) {
+	var (
+		levels []float64
+		errs   []error
+	)
+	for _, src := range b.sources {
+		l, err := src.Level(now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		levels = append(levels, math.Min(math.Max(l, 0), 1))
+	}
+	if len(levels) == 0 {
+		return b.applied, false, errors.Join(errs...)
+	}
+	target := b.combine(levels)
+
+	if b.updated.IsZero() || b.smoothing <= 0 {
+		b.smoothed = target
+		b.applied = target
+	} else {
+		alpha := 1 - math.Exp(-float64(now.Sub(b.updated))/float64(b.smoothing))
+		b.smoothed += (target - b.smoothed) * alpha
+		// The average only approaches the target, so settle on full day or
+		// night once within the hysteresis rather than stopping just short.
+		if (target == 0 || target == 1) && math.Abs(target-b.smoothed) <= b.hysteresis {
+			b.smoothed = target
+		}
+		if math.Abs(b.smoothed-b.applied) > b.hysteresis || b.smoothed == target {
+			b.applied = b.smoothed
+		}
+	}
+	b.updated = now
+	return b.applied, true, errors.Join(errs...)
+}
@@ -0,0 +1,166 @@
+package display
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+// fixedSource is a BrightnessSource returning level, or err if set.
+type fixedSource struct {
+	level float64
+	err   error
+}
+
+func (f *fixedSource) Level(time.Time) (float64, error) { return f.level, f.err }
+
+func TestIlluminanceSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "in_illuminance_input")
+	src := illuminanceSource{path: path, scale: 1, darkLux: 1, brightLux: 100}
+
+	tests := []struct {
+		contents string
+		want     float64
+	}{
+		{"0\n", 0},
+		{"1", 0},
+		{"10.0\n", 0.5}, // one decade of two
+		{"100", 1},
+		{"5000", 1},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(path, []byte(tt.contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := src.Level(time.Time{})
+		if err != nil {
+			t.Fatalf("%q: %v", tt.contents, err)
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%q lux: got %v, want %v", tt.contents, got, tt.want)
+		}
+	}
+
+	os.WriteFile(path, []byte("garbage"), 0o644)
+	if _, err := src.Level(time.Time{}); err == nil {
+		t.Error("expected an error for an unreadable value")
+	}
+	if _, err := (illuminanceSource{path: filepath.Join(t.TempDir(), "missing")}).Level(time.Time{}); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestIlluminanceSource_Scale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "in_illuminance_raw")
+	os.WriteFile(path, []byte("1000\n"), 0o644)
+	src := illuminanceSource{path: path, scale: 0.01, darkLux: 1, brightLux: 100}
+	if got, _ := src.Level(time.Time{}); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("got %v, want 0.5 for 10 lux", got)
+	}
+}
+
+func TestScheduleSource(t *testing.T) {
+	s, err := newScheduleSource([]config.ScheduleEntry{
+		{At: "22:30", Level: 0},
+		{At: "07:00", Level: 1},
+		{At: "18:00", Level: 0.5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		at   time.Duration
+		want float64
+	}{
+		{2 * time.Hour, 0}, // wraps to 22:30 the day before
+		{7 * time.Hour, 1},
+		{12 * time.Hour, 1},
+		{18*time.Hour + time.Minute, 0.5},
+		{23 * time.Hour, 0},
+	}
+	for _, tt := range tests {
+		if got, _ := s.Level(day.Add(tt.at)); got != tt.want {
+			t.Errorf("at %v: got %v, want %v", tt.at, got, tt.want)
+		}
+	}
+
+	if _, err := newScheduleSource([]config.ScheduleEntry{{At: "7am"}}); err == nil {
+		t.Error("expected an error for a bad time")
+	}
+}
+
+func TestBrightnessCombine(t *testing.T) {
+	for combine, want := range map[string]float64{"min": 0.2, "max": 0.8, "average": 0.5} {
+		b, err := BrightnessFromConfig(config.BrightnessConfig{Combine: combine}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.sources = []BrightnessSource{&fixedSource{level: 0.2}, &fixedSource{level: 0.8}, &fixedSource{level: 0.5}}
+		if got, ok, _ := b.Update(time.Now()); !ok || math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s: got %v, %v; want %v", combine, got, ok, want)
+		}
+	}
+}
+
+func TestBrightnessUpdate_SmoothingAndHysteresis(t *testing.T) {
+	src := &fixedSource{level: 0}
+	b := &Brightness{
+		sources:    []BrightnessSource{src},
+		combine:    brightnessCombines["min"],
+		smoothing:  10 * time.Second,
+		hysteresis: 0.05,
+	}
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if got, _, _ := b.Update(start); got != 0 {
+		t.Fatalf("first reading: got %v, want it applied directly", got)
+	}
+
+	// A small wobble stays within the hysteresis.
+	src.level = 0.08
+	if got, _, _ := b.Update(start.Add(5 * time.Second)); got != 0 {
+		t.Errorf("wobble: got %v, want 0 held", got)
+	}
+
+	// Turning the lights on moves the level gradually, then settles on full day.
+	src.level = 1
+	got, _, _ := b.Update(start.Add(15 * time.Second))
+	if got <= 0.05 || got >= 1 {
+		t.Errorf("after 10s: got %v, want partway to 1", got)
+	}
+	for i := 2; i < 10; i++ {
+		got, _, _ = b.Update(start.Add(time.Duration(5+10*i) * time.Second))
+	}
+	if got != 1 {
+		t.Errorf("settled: got %v, want 1", got)
+	}
+}
+
+func TestBrightnessUpdate_SourceErrors(t *testing.T) {
+	failing := &fixedSource{err: os.ErrNotExist}
+	b := &Brightness{
+		sources: []BrightnessSource{failing, &fixedSource{level: 0.7}},
+		combine: brightnessCombines["min"],
+	}
+	got, ok, err := b.Update(time.Now())
+	if !ok || got != 0.7 || err == nil {
+		t.Errorf("one failing source: got %v, %v, %v; want 0.7 from the other and its error", got, ok, err)
+	}
+
+	b.sources = []BrightnessSource{failing}
+	if got, ok, _ := b.Update(time.Now()); ok || got != 0.7 {
+		t.Errorf("all failing: got %v, %v; want the previous level kept", got, ok)
+	}
+}
+
+func TestScaleBrightness(t *testing.T) {
+	if got := ScaleBrightness(0.5, 200, 50); got != 125 {
+		t.Errorf("got %d, want 125", got)
+	}
+}
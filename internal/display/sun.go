@@ -0,0 +1,149 @@
+package display
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+
+	sunrise "github.com/nathan-osman/go-sunrise"
+	"github.com/rs/zerolog/log"
+)
+
+// horizon is the sun's elevation at sunrise and sunset, allowing for refraction.
+const horizon = -0.833
+
+// PlanPoint is a level the sun curve passes through at Time.
+type PlanPoint struct {
+	Time  time.Time
+	Level float64
+	Label string
+}
+
+// SunCurve ramps between night and day as the sun crosses a twilight
+// elevation, easing between its points and any clock-time keyframes. Where the
+// sun never crosses it, as in polar summer and winter, the day is held at day
+// or night.
+type SunCurve struct {
+	latitude  float64
+	longitude float64
+	elevation float64 // the ramps are centered where the sun crosses it
+	ramp      time.Duration
+	easing    Easing
+	keyframes []scheduleEntry
+
+	date   time.Time   // the day points were calculated for
+	points []PlanPoint // the day before date to the day after, so ramps cross midnight
+}
+
+// NewSunCurve builds the curve for a location from config.SunConfig, falling
+// back to linear easing if the configured curve is unknown.
+func NewSunCurve(latitude, longitude float64, sc config.SunConfig) (*SunCurve, error) {
+	elevation, ok := config.Twilights[sc.Twilight]
+	if !ok {
+		return nil, fmt.Errorf("unknown twilight %q", sc.Twilight)
+	}
+	easing, err := ParseEasing(sc.Easing)
+	if err != nil {
+		log.Warn().Err(err).Msg("Invalid sun easing, using linear")
+	}
+	s := &SunCurve{
+		latitude:  latitude,
+		longitude: longitude,
+		elevation: elevation,
+		ramp:      time.Duration(sc.RampMin * float64(time.Minute)),
+		easing:    easing,
+	}
+	if len(sc.Keyframes) > 0 {
+		keyframes, err := newScheduleSource(sc.Keyframes)
+		if err != nil {
+			return nil, err
+		}
+		s.keyframes = keyframes.entries
+	}
+	return s, nil
+}
+
+// Level returns the curve's level at now.
+func (s *SunCurve) Level(now time.Time) (float64, error) {
+	today := midnight(now)
+	if !today.Equal(s.date) {
+		s.date = today
+		s.points = slices.Concat(s.dayPoints(today.AddDate(0, 0, -1)), s.dayPoints(today), s.dayPoints(today.AddDate(0, 0, 1)))
+		slices.SortStableFunc(s.points, func(a, b PlanPoint) int { return a.Time.Compare(b.Time) })
+	}
+	return curveLevel(s.points, now, s.easing), nil
+}
+
+// Plan returns the points the curve passes through on day, in day's location.
+func (s *SunCurve) Plan(day time.Time) []PlanPoint {
+	points := s.dayPoints(midnight(day))
+	slices.SortStableFunc(points, func(a, b PlanPoint) int { return a.Time.Compare(b.Time) })
+	return points
+}
+
+// Night reports whether the sun is below the horizon at now.
+func (s *SunCurve) Night(now time.Time) bool {
+	return sunrise.Elevation(s.latitude, s.longitude, now.UTC()) < horizon
+}
+
+// dayPoints returns the ramps and keyframes of the day starting at day.
+func (s *SunCurve) dayPoints(day time.Time) []PlanPoint {
+	var points []PlanPoint
+	dawn, dusk, err := calcCrossings(day, s.longitude, s.latitude, s.elevation)
+	if err == nil {
+		points = rampPoints(dawn, dusk, s.ramp)
+	} else {
+		noon := clockTime(day, 12*time.Hour)
+		if sunrise.Elevation(s.latitude, s.longitude, noon.UTC()) > s.elevation {
+			points = []PlanPoint{{Time: noon, Level: 1, Label: "polar day"}}
+		} else {
+			points = []PlanPoint{{Time: noon, Level: 0, Label: "polar night"}}
+		}
+	}
+	for _, k := range s.keyframes {
+		points = append(points, PlanPoint{Time: clockTime(day, k.offset), Level: k.level, Label: "keyframe"})
+	}
+	return points
+}
+
+// rampPoints returns the points of ramps of length ramp centered on dawn and dusk.
+func rampPoints(dawn, dusk time.Time, ramp time.Duration) []PlanPoint {
+	half := ramp / 2
+	return []PlanPoint{
+		{Time: dawn.Add(-half), Level: 0, Label: "dawn ramp starts"},
+		{Time: dawn.Add(half), Level: 1, Label: "dawn ramp ends"},
+		{Time: dusk.Add(-half), Level: 1, Label: "dusk ramp starts"},
+		{Time: dusk.Add(half), Level: 0, Label: "dusk ramp ends"},
+	}
+}
+
+// curveLevel eases between the points either side of now, holding the first
+// and last levels beyond them. points must be sorted by time.
+func curveLevel(points []PlanPoint, now time.Time, easing Easing) float64 {
+	if len(points) == 0 {
+		return 0
+	}
+	if !now.After(points[0].Time) {
+		return points[0].Level
+	}
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		if now.Before(b.Time) {
+			x := float64(now.Sub(a.Time)) / float64(b.Time.Sub(a.Time))
+			return a.Level + (b.Level-a.Level)*easing.ease(x)
+		}
+	}
+	return points[len(points)-1].Level
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// clockTime returns the time offset reads on the clock on day, which is not
+// day plus offset across a daylight saving change.
+func clockTime(day time.Time, offset time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(offset.Hours()), int(offset.Minutes())%60, 0, 0, day.Location())
+}
@@ -0,0 +1,159 @@
+package display
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+func newTestSunCurve(t *testing.T, lat, long float64, sc config.SunConfig) *SunCurve {
+	t.Helper()
+	if sc.Twilight == "" {
+		sc.Twilight = "sunrise"
+	}
+	if sc.RampMin == 0 {
+		sc.RampMin = 60
+	}
+	if sc.Easing == "" {
+		sc.Easing = "linear"
+	}
+	s, err := NewSunCurve(lat, long, sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func planTime(t *testing.T, plan []PlanPoint, label string) time.Time {
+	t.Helper()
+	for _, p := range plan {
+		if p.Label == label {
+			return p.Time
+		}
+	}
+	t.Fatalf("no %q in plan %+v", label, plan)
+	return time.Time{}
+}
+
+func TestSunCurve_Twilight(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, 6, 21, 0, 0, 0, 0, loc)
+
+	sunrise := newTestSunCurve(t, 37.9884, -122.0578, config.SunConfig{Twilight: "sunrise"}).Plan(day)
+	civil := newTestSunCurve(t, 37.9884, -122.0578, config.SunConfig{Twilight: "civil"}).Plan(day)
+	nautical := newTestSunCurve(t, 37.9884, -122.0578, config.SunConfig{Twilight: "nautical"}).Plan(day)
+
+	if len(sunrise) != 4 {
+		t.Fatalf("got %d points, want 4: %+v", len(sunrise), sunrise)
+	}
+	// Civil dawn is roughly half an hour before sunrise at this latitude.
+	early := planTime(t, sunrise, "dawn ramp starts").Sub(planTime(t, civil, "dawn ramp starts"))
+	if early < 20*time.Minute || early > 45*time.Minute {
+		t.Errorf("civil dawn is %v before sunrise, want about 30m", early)
+	}
+	if !planTime(t, nautical, "dawn ramp starts").Before(planTime(t, civil, "dawn ramp starts")) {
+		t.Error("nautical dawn should come before civil dawn")
+	}
+	if !planTime(t, nautical, "dusk ramp ends").After(planTime(t, civil, "dusk ramp ends")) {
+		t.Error("nautical dusk should come after civil dusk")
+	}
+}
+
+func TestSunCurve_RampAndEasing(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, 6, 21, 0, 0, 0, 0, loc)
+	s := newTestSunCurve(t, 37.9884, -122.0578, config.SunConfig{RampMin: 40, Easing: "ease_in"})
+
+	plan := s.Plan(day)
+	start, end := planTime(t, plan, "dawn ramp starts"), planTime(t, plan, "dawn ramp ends")
+	if got := end.Sub(start); got != 40*time.Minute {
+		t.Errorf("ramp: got %v, want 40m", got)
+	}
+	level, _ := s.Level(start.Add(20 * time.Minute))
+	if math.Abs(level-0.25) > 1e-9 {
+		t.Errorf("ease_in halfway through the ramp: got %v, want 0.25", level)
+	}
+	if level, _ := s.Level(day.Add(13 * time.Hour)); level != 1 {
+		t.Errorf("midday: got %v, want 1", level)
+	}
+	if level, _ := s.Level(day.Add(2 * time.Hour)); level != 0 {
+		t.Errorf("night: got %v, want 0", level)
+	}
+}
+
+func TestSunCurve_Keyframes(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, 6, 21, 0, 0, 0, 0, loc)
+	s := newTestSunCurve(t, 37.9884, -122.0578, config.SunConfig{
+		Keyframes: []config.ScheduleEntry{{At: "23:00", Level: 0.4}},
+	})
+
+	if got := planTime(t, s.Plan(day), "keyframe"); !got.Equal(day.Add(23 * time.Hour)) {
+		t.Errorf("keyframe: got %v, want 23:00", got)
+	}
+	if level, _ := s.Level(day.Add(23 * time.Hour)); math.Abs(level-0.4) > 1e-9 {
+		t.Errorf("at the keyframe: got %v, want 0.4", level)
+	}
+	// The curve eases from the keyframe down to the next dawn ramp, across midnight.
+	level, _ := s.Level(day.Add(25 * time.Hour))
+	if level <= 0 || level >= 0.4 {
+		t.Errorf("after the keyframe: got %v, want between 0 and 0.4", level)
+	}
+}
+
+func TestSunCurve_Polar(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Oslo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Longyearbyen, Svalbard.
+	s := newTestSunCurve(t, 78.22, 15.65, config.SunConfig{})
+
+	summer := time.Date(2024, 6, 21, 0, 0, 0, 0, loc)
+	winter := time.Date(2024, 12, 21, 0, 0, 0, 0, loc)
+
+	if _, _, err := calcCrossings(summer, 15.65, 78.22, horizon); err == nil {
+		t.Error("expected no sunrise in polar summer")
+	}
+	if plan := s.Plan(summer); len(plan) != 1 || plan[0].Label != "polar day" {
+		t.Errorf("summer plan: got %+v, want polar day", plan)
+	}
+	if plan := s.Plan(winter); len(plan) != 1 || plan[0].Label != "polar night" {
+		t.Errorf("winter plan: got %+v, want polar night", plan)
+	}
+
+	for _, h := range []int{0, 6, 12, 23} {
+		if level, _ := s.Level(summer.Add(time.Duration(h) * time.Hour)); level != 1 {
+			t.Errorf("summer %02d:00: got %v, want 1", h, level)
+		}
+		if level, _ := s.Level(winter.Add(time.Duration(h) * time.Hour)); level != 0 {
+			t.Errorf("winter %02d:00: got %v, want 0", h, level)
+		}
+	}
+	if s.Night(summer) {
+		t.Error("summer midnight should not be night")
+	}
+	if !s.Night(winter.Add(12 * time.Hour)) {
+		t.Error("winter noon should be night")
+	}
+}
+
+func TestNewSunCurve_Invalid(t *testing.T) {
+	if _, err := NewSunCurve(0, 0, config.SunConfig{Twilight: "dusky", Easing: "linear"}); err == nil {
+		t.Error("expected an error for an unknown twilight")
+	}
+	if _, err := NewSunCurve(0, 0, config.SunConfig{Twilight: "civil", Keyframes: []config.ScheduleEntry{{At: "7am"}}}); err == nil {
+		t.Error("expected an error for a bad keyframe time")
+	}
+}
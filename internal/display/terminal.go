@@ -0,0 +1,110 @@
+package display
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+// terminalColumns is the number of LEDs drawn per row by the terminal backend.
+const terminalColumns = 12
+
+// terminalEngine is a wsEngine that draws the strip to a terminal with ANSI
+// true-color blocks, labelling each LED with its station.
+type terminalEngine struct {
+	out        io.Writer
+	strips     [][]uint32
+	brightness []int
+	rgbw       []bool
+	addresses  []address
+	labels     []string
+}
+
+// NewTerminal returns Leds drawn to out instead of a strip, so the server can run
+// without a Raspberry Pi. labels maps LED numbers to the station shown under each.
+func NewTerminal(out io.Writer, hw config.HardwareConfig, labels map[int]string) (*Leds, error) {
+	addresses := addressMap(hw.Segments)
+	t := &terminalEngine{
+		out:       out,
+		addresses: addresses,
+		labels:    make([]string, len(addresses)),
+	}
+	for _, ch := range hw.Channels {
+		t.strips = append(t.strips, make([]uint32, ch.LedCount))
+		t.brightness = append(t.brightness, ch.Brightness)
+		t.rgbw = append(t.rgbw, isRGBW(ch.StripType))
+	}
+	for i := range t.labels {
+		t.labels[i] = strconv.Itoa(i)
+		if station, ok := labels[i]; ok {
+			t.labels[i] = station
+		}
+	}
+
+	for i, a := range addresses {
+		if a.channel >= len(t.strips) || a.index >= len(t.strips[a.channel]) {
+			return nil, fmt.Errorf("LED %d addresses channel %d LED %d, which is not configured", i, a.channel, a.index)
+		}
+	}
+
+	leds := newWithEngine(t)
+	leds.configure(hw)
+	return leds, nil
+}
+
+func (t *terminalEngine) Init() error { return nil }
+func (t *terminalEngine) Wait() error { return nil }
+
+func (t *terminalEngine) Fini() {
+	fmt.Fprint(t.out, "\x1b[0m\n")
+}
+
+func (t *terminalEngine) Leds(channel int) []uint32 {
+	return t.strips[channel]
+}
+
+func (t *terminalEngine) SetBrightness(channel int, brightness int) {
+	t.brightness[channel] = brightness
+}
+
+// Render redraws every LED in place from the top left of the terminal.
+func (t *terminalEngine) Render() error {
+	var b bytes.Buffer
+	b.WriteString("\x1b[H")
+	for row := 0; row < len(t.addresses); row += terminalColumns {
+		end := min(row+terminalColumns, len(t.addresses))
+		for i := row; i < end; i++ {
+			r, g, bl := t.rgb(t.addresses[i])
+			fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm█████\x1b[0m ", r, g, bl)
+		}
+		b.WriteString("\x1b[K\n")
+		for i := row; i < end; i++ {
+			label := t.labels[i]
+			if len(label) > 5 {
+				label = label[:5]
+			}
+			fmt.Fprintf(&b, "%-5s ", label)
+		}
+		b.WriteString("\x1b[K\n\n")
+	}
+	b.WriteString("\x1b[J")
+	_, err := t.out.Write(b.Bytes())
+	return err
+}
+
+// rgb returns the color shown by an LED after brightness, folding an RGBW white
+// level back into the RGB channels.
+func (t *terminalEngine) rgb(a address) (uint8, uint8, uint8) {
+	word := t.strips[a.channel][a.index]
+	white := uint32(0)
+	if t.rgbw[a.channel] {
+		white = word >> 24
+	}
+	scale := func(v uint32) uint8 {
+		return uint8(min(v+white, 0xff) * uint32(t.brightness[a.channel]) / 0xff)
+	}
+	return scale(word >> 16 & 0xff), scale(word >> 8 & 0xff), scale(word & 0xff)
+}
@@ -0,0 +1,62 @@
+package display
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/finack/twinkle/internal/config"
+
+	"golang.org/x/image/colornames"
+)
+
+func terminalHardware() config.HardwareConfig {
+	return config.HardwareConfig{
+		Channels: []config.ChannelConfig{
+			{StripType: "rgb", LedCount: 2, Brightness: 255},
+			{StripType: "grbw", White: "subtract", LedCount: 1, Brightness: 128},
+		},
+		Segments: []config.SegmentConfig{{Channel: 0, Count: 2}, {Channel: 1, Count: 1}},
+	}
+}
+
+func TestTerminalRender(t *testing.T) {
+	var out bytes.Buffer
+	leds, err := NewTerminal(&out, terminalHardware(), map[int]string{0: "KSFO", 2: "KOAK"})
+	if err != nil {
+		t.Fatalf("NewTerminal: %v", err)
+	}
+
+	leds.Display(0, red)
+	leds.Display(2, colornames.White)
+	if err := leds.Ws.Render(); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"\x1b[38;2;255;0;0m",     // red at full brightness
+		"\x1b[38;2;0;0;0m",       // LED 1 off
+		"\x1b[38;2;128;128;128m", // white from the white die at half brightness
+		"KSFO", "1    ", "KOAK",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%q", want, got)
+		}
+	}
+
+	out.Reset()
+	leds.Ws.SetBrightness(0, 0)
+	leds.Ws.Render()
+	if strings.Contains(out.String(), "255;0;0m") {
+		t.Error("red still shown after channel brightness set to 0")
+	}
+}
+
+func TestNewTerminal_BadSegment(t *testing.T) {
+	hw := terminalHardware()
+	hw.Segments[1].Channel = 2
+	if _, err := NewTerminal(&bytes.Buffer{}, hw, nil); err == nil {
+		t.Error("expected error for segment on unconfigured channel")
+	}
+}
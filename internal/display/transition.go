@@ -0,0 +1,40 @@
+package display
+
+import (
+	"image/color"
+	"math"
+	"time"
+)
+
+// transitionPriority keeps crossfades beneath every other effect, so overlays such
+// as lightning keep animating while the base color changes.
+const transitionPriority = math.MinInt
+
+// Transition describes how an LED crossfades to a new target color.
+type Transition struct {
+	Duration time.Duration
+	Easing   Easing
+}
+
+// Transition crossfades an LED from the color it currently shows to its new base
+// color, replacing any crossfade already in progress on it. A zero Duration
+// switches instantly.
+func (a *Animator) Transition(led int, from color.RGBA, tr Transition) {
+	if id, ok := a.transitions[led]; ok {
+		a.Detach(id)
+		delete(a.transitions, led)
+	}
+	if tr.Duration <= 0 {
+		return
+	}
+
+	a.transitions[led] = a.Attach([]int{led}, Effect{
+		Kind:      EffectFade,
+		Color:     from,
+		Intensity: 1,
+		Duration:  tr.Duration,
+		Easing:    tr.Easing,
+		Priority:  transitionPriority,
+		Until:     a.clock.Now().Add(tr.Duration),
+	})
+}
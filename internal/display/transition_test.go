@@ -0,0 +1,89 @@
+package display
+
+import (
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+func TestAnimatorTransition(t *testing.T) {
+	clock := newFakeClock()
+	a := NewAnimator(clock, 1)
+	a.Transition(0, red, Transition{Duration: time.Second, Easing: EaseLinear})
+
+	steps := []struct {
+		advance time.Duration
+		want    color.RGBA
+	}{
+		{0, red},
+		{500 * time.Millisecond, BlendColors(green, red, 0.5)},
+		{500 * time.Millisecond, green},
+	}
+	for i, s := range steps {
+		clock.Advance(s.advance)
+		colors := []color.RGBA{green}
+		a.Apply(colors)
+		if colors[0] != s.want {
+			t.Errorf("step %d: got %v, want %v", i, colors[0], s.want)
+		}
+	}
+	if a.Animating() {
+		t.Error("expected transition layer to be dropped once complete")
+	}
+}
+
+func TestAnimatorTransition_BeneathEffects(t *testing.T) {
+	clock := newFakeClock()
+	a := NewAnimator(clock, 1)
+	a.SetLedEffects(0, []Effect{{Kind: EffectBlink, Color: white, Rate: 1, Intensity: 1}})
+	a.Transition(0, red, Transition{Duration: time.Second})
+
+	colors := []color.RGBA{green}
+	a.Apply(colors)
+	if colors[0] != white {
+		t.Errorf("got %v, want the blink drawn over the crossfade", colors[0])
+	}
+}
+
+func TestAnimatorTransition_Replaces(t *testing.T) {
+	a := NewAnimator(newFakeClock(), 1)
+	a.Transition(0, red, Transition{Duration: time.Second})
+	a.Transition(0, white, Transition{Duration: time.Second})
+
+	colors := []color.RGBA{green}
+	a.Apply(colors)
+	if colors[0] != white {
+		t.Errorf("got %v, want the latest crossfade only", colors[0])
+	}
+
+	a.Transition(0, red, Transition{})
+	if a.Animating() {
+		t.Error("expected a zero-length transition to cancel the crossfade")
+	}
+}
+
+func TestEasing(t *testing.T) {
+	for _, e := range []Easing{EaseLinear, EaseIn, EaseOut, EaseInOut} {
+		if e.ease(0) != 0 || e.ease(1) != 1 {
+			t.Errorf("easing %v: endpoints %v, %v; want 0, 1", e, e.ease(0), e.ease(1))
+		}
+	}
+	if EaseIn.ease(0.5) >= 0.5 || EaseOut.ease(0.5) <= 0.5 {
+		t.Error("ease_in should lag and ease_out should lead linear progress")
+	}
+	if _, err := ParseEasing("bounce"); err == nil {
+		t.Error("expected error for unknown easing")
+	}
+}
+
+func TestTransitionFromConfig(t *testing.T) {
+	tr := transitionFromConfig(config.TransitionConfig{DurationMS: 800, Easing: "ease_out"})
+	if tr.Duration != 800*time.Millisecond || tr.Easing != EaseOut {
+		t.Errorf("got %+v", tr)
+	}
+	if tr := transitionFromConfig(config.TransitionConfig{Disabled: true, DurationMS: 800}); tr.Duration != 0 {
+		t.Errorf("disabled: got %+v, want zero transition", tr)
+	}
+}
@@ -0,0 +1,55 @@
+package display
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// WhiteExtraction selects how the white die of an RGBW LED is derived from an
+// RGB color.
+type WhiteExtraction int
+
+const (
+	// WhiteOff leaves the white die dark; used for RGB strips.
+	WhiteOff WhiteExtraction = iota
+	// WhiteSubtract moves the grey component shared by all three channels onto
+	// the white die, keeping the color and lowering power draw.
+	WhiteSubtract
+	// WhiteAdd drives the white die with the shared grey component while keeping
+	// the RGB channels, making whites and pastels brighter.
+	WhiteAdd
+)
+
+var whiteExtractionNames = map[string]WhiteExtraction{
+	"none":     WhiteOff,
+	"subtract": WhiteSubtract,
+	"add":      WhiteAdd,
+}
+
+// ParseWhiteExtraction converts a config name such as "subtract" into a WhiteExtraction.
+func ParseWhiteExtraction(s string) (WhiteExtraction, error) {
+	w, ok := whiteExtractionNames[strings.ToLower(s)]
+	if !ok {
+		return WhiteOff, fmt.Errorf("unknown white extraction %q", s)
+	}
+	return w, nil
+}
+
+// pack converts c into the strip's 0xWWRRGGBB word.
+func (w WhiteExtraction) pack(c color.RGBA) uint32 {
+	white := min(c.R, c.G, c.B)
+	switch w {
+	case WhiteSubtract:
+		c.R, c.G, c.B = c.R-white, c.G-white, c.B-white
+	case WhiteAdd:
+	default:
+		white = 0
+	}
+	return ParseRGBWtoUint32(c, white)
+}
+
+// ParseRGBWtoUint32 packs a color and white level for an RGBW strip.
+func ParseRGBWtoUint32(c color.RGBA, white uint8) uint32 {
+	return uint32(white)<<24 | ParseRGBAtoUint32(c)
+}
@@ -0,0 +1,66 @@
+package display
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/finack/twinkle/internal/config"
+
+	"golang.org/x/image/colornames"
+)
+
+func TestWhiteExtractionPack(t *testing.T) {
+	grey := color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}
+	warm := color.RGBA{R: 0xfa, G: 0xeb, B: 0xd7, A: 0xff} // Antiquewhite
+
+	tests := []struct {
+		name  string
+		white WhiteExtraction
+		in    color.RGBA
+		want  uint32
+	}{
+		{"off keeps rgb", WhiteOff, grey, 0x00808080},
+		{"subtract grey", WhiteSubtract, grey, 0x80000000},
+		{"subtract white", WhiteSubtract, colornames.White, 0xff000000},
+		{"subtract tint", WhiteSubtract, warm, 0xd7231400},
+		{"subtract saturated", WhiteSubtract, colornames.Red, 0x00ff0000},
+		{"add grey", WhiteAdd, grey, 0x80808080},
+		{"add saturated", WhiteAdd, colornames.Red, 0x00ff0000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.white.pack(tt.in); got != tt.want {
+				t.Errorf("pack(%v) = %#08x, want %#08x", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWhiteExtraction(t *testing.T) {
+	if w, err := ParseWhiteExtraction("Add"); err != nil || w != WhiteAdd {
+		t.Errorf("got %v, %v; want WhiteAdd", w, err)
+	}
+	if _, err := ParseWhiteExtraction("brightest"); err == nil {
+		t.Error("expected error for unknown extraction")
+	}
+}
+
+func TestLedsDisplay_RGBW(t *testing.T) {
+	mock := newTwoChannelMock(2, 2)
+	l := newWithEngine(mock)
+	l.addresses = []address{{0, 0}, {1, 0}}
+	l.white = whiteExtractions(config.HardwareConfig{Channels: []config.ChannelConfig{
+		{StripType: "grb", White: "subtract"},
+		{StripType: "grbw", White: "subtract"},
+	}})
+
+	l.Display(0, colornames.White)
+	l.Display(1, colornames.White)
+
+	if mock.leds[0] != 0x00ffffff {
+		t.Errorf("RGB channel = %#08x, want 0x00ffffff", mock.leds[0])
+	}
+	if mock.channel1[0] != 0xff000000 {
+		t.Errorf("RGBW channel = %#08x, want 0xff000000", mock.channel1[0])
+	}
+}
@@ -0,0 +1,142 @@
+package metardata
+
+import (
+	"strings"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxChanges bounds the change log kept in State.
+const maxChanges = 200
+
+// Change records a significant update to a station's observation: a new flight
+// category, a SPECI, or a correction replacing an earlier report.
+type Change struct {
+	Time            time.Time `json:"time"`
+	StationID       string    `json:"station"`
+	ObservationTime string    `json:"observation_time"`
+	MetarType       string    `json:"metar_type"`
+	Corrected       bool      `json:"corrected"`
+	From            string    `json:"from"`
+	To              string    `json:"to"`
+	RawText         string    `json:"raw_text"`
+}
+
+// IsSpeci reports whether the report is a SPECI rather than a routine METAR.
+func (m Metar) IsSpeci() bool {
+	return strings.EqualFold(m.MetarType, "SPECI")
+}
+
+// IsCorrected reports whether the report corrects an earlier one.
+func (m Metar) IsCorrected() bool {
+	return strings.EqualFold(m.Corrected, "TRUE")
+}
+
+// ObservedAt parses the observation time.
+func (m Metar) ObservedAt() (time.Time, error) {
+	return time.Parse(time.RFC3339, m.ObservationTime)
+}
+
+// isStale reports whether the report was observed more than maxAge before now.
+// Reports without a valid observation time are never stale; a zero maxAge
+// disables the check.
+func (m Metar) isStale(now time.Time, maxAge time.Duration) bool {
+	observed, err := m.ObservedAt()
+	if err != nil || maxAge <= 0 {
+		return false
+	}
+	return now.Sub(observed) > maxAge
+}
+
+// supersedes reports whether m should replace prev as a station's current report:
+// it is a later observation, or a correction of the same one.
+func (m Metar) supersedes(prev Metar) bool {
+	if m.ObservationTime != prev.ObservationTime {
+		return m.ObservationTime > prev.ObservationTime
+	}
+	return m.IsCorrected() && !prev.IsCorrected()
+}
+
+// latestMetars keeps one report per station, preferring the latest observation and,
+// for the same observation time, the corrected report.
+func latestMetars(metars []Metar) []Metar {
+	index := map[string]int{}
+	var latest []Metar
+	for _, m := range metars {
+		i, ok := index[m.StationID]
+		if !ok {
+			index[m.StationID] = len(latest)
+			latest = append(latest, m)
+			continue
+		}
+		if m.supersedes(latest[i]) {
+			latest[i] = m
+		}
+	}
+	return latest
+}
+
+// diffMetars returns the changes between the previous and next reports.
+func diffMetars(prev, next []Metar, now time.Time) []Change {
+	before := make(map[string]Metar, len(prev))
+	for _, m := range prev {
+		before[m.StationID] = m
+	}
+
+	var changes []Change
+	for _, m := range next {
+		// Stations appearing for the first time, such as on startup, are not changes.
+		old, seen := before[m.StationID]
+		if !seen || old.RawText == m.RawText {
+			continue
+		}
+
+		corrected := m.IsCorrected() && m.ObservationTime == old.ObservationTime
+		if !corrected && !m.IsSpeci() && old.FlightCategory == m.FlightCategory {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Time:            now,
+			StationID:       m.StationID,
+			ObservationTime: m.ObservationTime,
+			MetarType:       m.MetarType,
+			Corrected:       m.IsCorrected(),
+			From:            old.FlightCategory,
+			To:              m.FlightCategory,
+			RawText:         m.RawText,
+		})
+	}
+	return changes
+}
+
+// speciEffect returns the attention effect for a station that issued a SPECI
+// within the configured highlight window, or false if none should be shown.
+func speciEffect(c config.SpeciConfig, m Metar, now time.Time) (display.Effect, bool) {
+	if c.Disabled || !m.IsSpeci() {
+		return display.Effect{}, false
+	}
+
+	observed, err := m.ObservedAt()
+	if err != nil {
+		log.Warn().Err(err).Str("station", m.StationID).Msg("Could not parse observation time")
+		return display.Effect{}, false
+	}
+	until := observed.Add(time.Duration(c.HighlightMin) * time.Minute)
+	if !now.Before(until) {
+		return display.Effect{}, false
+	}
+
+	e, err := display.EffectFromConfig(c.Effect)
+	if err != nil {
+		log.Warn().Err(err).Msg("Invalid SPECI effect")
+		return display.Effect{}, false
+	}
+	e.Until = until
+	e.Priority = prioritySpeci
+	return e, true
+}
@@ -0,0 +1,116 @@
+package metardata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+)
+
+func TestLatestMetars(t *testing.T) {
+	metars := []Metar{
+		{StationID: "KOAK", ObservationTime: "2024-06-21T18:53:00Z", RawText: "original"},
+		{StationID: "KSFO", ObservationTime: "2024-06-21T18:56:00Z"},
+		{StationID: "KOAK", ObservationTime: "2024-06-21T18:53:00Z", Corrected: "TRUE", RawText: "corrected"},
+		{StationID: "KSFO", ObservationTime: "2024-06-21T17:56:00Z"},
+	}
+	got := latestMetars(metars)
+	if len(got) != 2 {
+		t.Fatalf("got %d metars, want 2", len(got))
+	}
+	if got[0].StationID != "KOAK" || got[0].RawText != "corrected" {
+		t.Errorf("KOAK: got %+v, want the corrected report", got[0])
+	}
+	if got[1].ObservationTime != "2024-06-21T18:56:00Z" {
+		t.Errorf("KSFO: got %q, want the latest observation", got[1].ObservationTime)
+	}
+}
+
+func TestDiffMetars(t *testing.T) {
+	now := time.Date(2024, 6, 21, 19, 0, 0, 0, time.UTC)
+	prev := []Metar{
+		{StationID: "KOAK", ObservationTime: "2024-06-21T17:53:00Z", FlightCategory: "VFR", RawText: "KOAK 1753"},
+		{StationID: "KSFO", ObservationTime: "2024-06-21T17:56:00Z", FlightCategory: "IFR", RawText: "KSFO 1756"},
+		{StationID: "KSQL", ObservationTime: "2024-06-21T17:53:00Z", FlightCategory: "VFR", RawText: "KSQL 1753"},
+		{StationID: "KHAF", ObservationTime: "2024-06-21T17:53:00Z", FlightCategory: "VFR", RawText: "KHAF 1753"},
+	}
+	next := []Metar{
+		// Category change.
+		{StationID: "KOAK", ObservationTime: "2024-06-21T18:53:00Z", FlightCategory: "MVFR", RawText: "KOAK 1853"},
+		// Unchanged report.
+		{StationID: "KSFO", ObservationTime: "2024-06-21T17:56:00Z", FlightCategory: "IFR", RawText: "KSFO 1756"},
+		// Correction of the same observation, same category.
+		{StationID: "KSQL", ObservationTime: "2024-06-21T17:53:00Z", FlightCategory: "VFR", Corrected: "TRUE", RawText: "KSQL 1753 COR"},
+		// New routine report, same category.
+		{StationID: "KHAF", ObservationTime: "2024-06-21T18:53:00Z", FlightCategory: "VFR", RawText: "KHAF 1853"},
+		// New station.
+		{StationID: "KAPC", ObservationTime: "2024-06-21T18:54:00Z", FlightCategory: "VFR", MetarType: "SPECI", RawText: "KAPC 1854"},
+	}
+
+	changes := diffMetars(prev, next, now)
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2: %+v", len(changes), changes)
+	}
+	if c := changes[0]; c.StationID != "KOAK" || c.From != "VFR" || c.To != "MVFR" || !c.Time.Equal(now) {
+		t.Errorf("KOAK change: got %+v", c)
+	}
+	if c := changes[1]; c.StationID != "KSQL" || !c.Corrected {
+		t.Errorf("KSQL change: got %+v", c)
+	}
+}
+
+func TestDiffMetars_Speci(t *testing.T) {
+	prev := []Metar{{StationID: "KOAK", FlightCategory: "VFR", RawText: "KOAK 1853"}}
+	next := []Metar{{StationID: "KOAK", FlightCategory: "VFR", MetarType: "SPECI", RawText: "KOAK 1910"}}
+	if changes := diffMetars(prev, next, time.Now()); len(changes) != 1 || changes[0].MetarType != "SPECI" {
+		t.Errorf("got %+v, want one SPECI change", changes)
+	}
+}
+
+func TestStateSetMetars_BoundsChangeLog(t *testing.T) {
+	s := NewState(config.Config{})
+	now := time.Now()
+	for i := 0; i < maxChanges+10; i++ {
+		cat := "VFR"
+		if i%2 == 1 {
+			cat = "IFR"
+		}
+		s.setMetars([]Metar{{StationID: "KOAK", FlightCategory: cat, RawText: cat + string(rune('a'+i%26))}}, now)
+	}
+	if n := len(s.Changes()); n != maxChanges {
+		t.Errorf("got %d changes, want %d", n, maxChanges)
+	}
+}
+
+func TestSpeciEffect(t *testing.T) {
+	c := config.SpeciConfig{
+		HighlightMin: 15,
+		Effect:       config.EffectConfig{Effect: "blink", Color: "#ffffff", Rate: 6, Intensity: 0.7},
+	}
+	observed := time.Date(2024, 6, 21, 18, 54, 0, 0, time.UTC)
+	speci := Metar{StationID: "KAPC", MetarType: "SPECI", ObservationTime: "2024-06-21T18:54:00Z"}
+
+	e, ok := speciEffect(c, speci, observed.Add(5*time.Minute))
+	if !ok {
+		t.Fatal("expected SPECI effect inside highlight window")
+	}
+	if e.Kind != display.EffectBlink || !e.Until.Equal(observed.Add(15*time.Minute)) {
+		t.Errorf("got %+v", e)
+	}
+
+	if _, ok := speciEffect(c, speci, observed.Add(20*time.Minute)); ok {
+		t.Error("expected no effect after highlight window")
+	}
+
+	routine := speci
+	routine.MetarType = "METAR"
+	if _, ok := speciEffect(c, routine, observed); ok {
+		t.Error("expected no effect for routine METAR")
+	}
+
+	c.Disabled = true
+	if _, ok := speciEffect(c, speci, observed); ok {
+		t.Error("expected no effect when disabled")
+	}
+}
@@ -15,10 +15,9 @@ import (
 
 	"github.com/gocarina/gocsv"
 	"github.com/rs/zerolog/log"
-	"golang.org/x/image/colornames"
 )
 
-var httpClient = &http.Client{}
+var httpClient = &http.Client{Timeout: 30 * time.Second}
 
 // https://www.aviationweather.gov/dataserver/fields?datatype=metar
 type Metar struct {
@@ -68,20 +67,31 @@ type Metar struct {
 	ElevationM                string `csv:"elevation_m"`                   // The elevation of the station that reported this METAR
 }
 
-func FetchRoutine(c config.Config, leds chan display.Pixel) chan bool {
+// statusRefreshInterval is how often the STATUS LED is re-evaluated between
+// fetches, so staleness and the end of a reload are shown promptly.
+const statusRefreshInterval = 5 * time.Second
+
+func FetchRoutine(c config.Config, renderer *display.Renderer, state *State) chan bool {
 	done := make(chan bool)
 
 	go func() {
 		metarRefresh := time.NewTicker(time.Duration(c.MetarRefreshRateS) * time.Second)
 		defer metarRefresh.Stop()
 
-		doFetchRoutine(c, leds)
+		statusRefresh := time.NewTicker(statusRefreshInterval)
+		defer statusRefresh.Stop()
+
+		doFetchRoutine(c, renderer, state)
 		for {
 			select {
 			case <-done:
 				return
 			case <-metarRefresh.C:
-				doFetchRoutine(c, leds)
+				doFetchRoutine(c, renderer, state)
+			case <-state.refresh:
+				renderMetars(c, renderer, state)
+			case <-statusRefresh.C:
+				renderStatus(c, renderer, state)
 			}
 		}
 	}()
@@ -89,59 +99,6 @@ func FetchRoutine(c config.Config, leds chan display.Pixel) chan bool {
 	return done
 }
 
-func flightCategoryToColor(category string) color.RGBA {
-	switch strings.ToUpper(category) {
-	case "VFR":
-		return colornames.Limegreen
-	case "MVFR":
-		return colornames.Blue
-	case "IFR":
-		return colornames.Red
-	case "LIFR":
-		return colornames.Mediumvioletred
-	case "", "NULL":
-		return colornames.Grey
-	default:
-		log.Warn().Str("flightCategory", category).Msg("Unknown flightCategory")
-		return colornames.Antiquewhite
-	}
-}
-
-// windyColorFor returns the "windy" hue-shifted variant of a flight category color.
-func windyColorFor(category string) color.RGBA {
-	switch strings.ToUpper(category) {
-	case "VFR":
-		return colornames.Yellowgreen
-	case "MVFR":
-		return colornames.Steelblue
-	case "IFR":
-		return colornames.Orangered
-	case "LIFR":
-		return colornames.Deeppink
-	default:
-		return colornames.Grey
-	}
-}
-
-// blendColors linearly interpolates between a and b; t is clamped to [0, 1].
-func blendColors(a, b color.RGBA, t float64) color.RGBA {
-	if t <= 0 {
-		return a
-	}
-	if t >= 1 {
-		return b
-	}
-	lerp := func(x, y uint8) uint8 {
-		return uint8(float64(x) + t*(float64(y)-float64(x)))
-	}
-	return color.RGBA{
-		R: lerp(a.R, b.R),
-		G: lerp(a.G, b.G),
-		B: lerp(a.B, b.B),
-		A: 0xff,
-	}
-}
-
 // windAdjustedColor shifts the base color toward the windy variant as effectiveWindKt
 // rises from lowKt to highKt, then fades toward white beyond highKt (capped at 40%).
 func windAdjustedColor(base, windy color.RGBA, effectiveWindKt, lowKt, highKt float64) color.RGBA {
@@ -149,55 +106,121 @@ func windAdjustedColor(base, windy color.RGBA, effectiveWindKt, lowKt, highKt fl
 		return base
 	}
 	if effectiveWindKt < highKt {
-		return blendColors(base, windy, (effectiveWindKt-lowKt)/(highKt-lowKt))
+		return display.BlendColors(base, windy, (effectiveWindKt-lowKt)/(highKt-lowKt))
 	}
 	whiteFraction := math.Min((effectiveWindKt-highKt)/15.0, 0.4)
-	return blendColors(windy, color.RGBA{R: 255, G: 255, B: 255, A: 255}, whiteFraction)
+	return display.BlendColors(windy, color.RGBA{R: 255, G: 255, B: 255, A: 255}, whiteFraction)
 }
 
-// FlightColor returns the LED color for a flight category at the given effective wind speed.
-// It is the single entry point for combining flight category and wind into a display color.
-func FlightColor(category string, effectiveWindKt, lowKt, highKt float64) color.RGBA {
-	return windAdjustedColor(
-		flightCategoryToColor(category),
-		windyColorFor(category),
-		effectiveWindKt, lowKt, highKt,
-	)
-}
-
-func doFetchRoutine(c config.Config, leds chan display.Pixel) {
+func doFetchRoutine(c config.Config, renderer *display.Renderer, state *State) {
 	metars, err := getMetars(c.Leds)
+	state.recordFetch(err, time.Now())
 	if err != nil {
 		log.Error().Err(err).Msg("Could not fetch metars, skipping")
+		renderStatus(c, renderer, state)
 		return
 	}
 
 	log.Info().Int("count", len(*metars)).Msg("Fetched Metars")
 
-	for _, metar := range *metars {
+	for _, ch := range state.setMetars(*metars, time.Now()) {
+		log.Info().
+			Str("station", ch.StationID).
+			Str("from", ch.From).
+			Str("to", ch.To).
+			Str("metarType", ch.MetarType).
+			Bool("corrected", ch.Corrected).
+			Msg("Station changed")
+	}
+	renderMetars(c, renderer, state)
+}
+
+// Effect priorities; effects with higher priorities are layered on top.
+const (
+	priorityDegraded = iota * 10
+	priorityPrecip
+	prioritySpeci
+	priorityLightning
+)
+
+// renderMetars submits a frame with a Pixel for every cached METAR using the
+// current State settings.
+func renderMetars(c config.Config, renderer *display.Renderer, state *State) {
+	now := time.Now()
+	mode := state.Mode()
+	profileName, profile := state.Profile()
+	_, theme := state.Theme()
+	staleAfter := time.Duration(c.StaleAfterMin) * time.Minute
+
+	var frame display.Frame
+	for _, metar := range state.Metars() {
 		ledNum, ok := c.Stations[metar.StationID]
 		if !ok {
 			log.Warn().Str("stationID", metar.StationID).Msg("Results included station not found in config")
 			continue
 		}
 
-		windKt, _ := strconv.ParseFloat(metar.WindSpeedKt, 64)
-		gustKt, _ := strconv.ParseFloat(metar.WindGustKt, 64)
-		effectiveKt := math.Max(windKt, gustKt)
+		if metar.isStale(now, staleAfter) {
+			log.Debug().Str("station", metar.StationID).Str("observed", metar.ObservationTime).Msg("Stale report")
+			frame.Pixels = append(frame.Pixels, display.Pixel{Num: ledNum, Color: theme.Stale})
+			continue
+		}
 
-		log.Debug().
-			Str("station", metar.StationID).
-			Float64("windKt", windKt).
-			Float64("gustKt", gustKt).
-			Msg("Wind")
+		var col color.RGBA
+		switch {
+		case mode == config.ModePressureTendency:
+			col = pressureTendencyColor(c.Pressure, theme, metar)
+		case mode == config.ModeAltimeter:
+			col = altimeterColor(c.Pressure, theme, metar)
+		case profileName != "":
+			col = minimumsColor(profile, theme, metar, c.Runways[metar.StationID])
+		default:
+			col = categoryColor(c, theme, metar)
+		}
 
-		base := flightCategoryToColor(metar.FlightCategory)
-		windy := windyColorFor(metar.FlightCategory)
-		col := windAdjustedColor(base, windy, effectiveKt, c.WindLowKt, c.WindHighKt)
-		leds <- display.Pixel{Num: ledNum, Color: col}
+		var effects []display.Effect
+		if e, ok := precipEffect(c.Precipitation, metar); ok {
+			log.Debug().Str("station", metar.StationID).Str("wx", metar.WxString).Msg("Precipitation")
+			effects = append(effects, e)
+		}
+		if e, ok := degradedEffect(c.Degraded, metar); ok {
+			effects = append(effects, e)
+		}
+		if e, ok := speciEffect(c.Speci, metar, now); ok {
+			log.Debug().Str("station", metar.StationID).Time("until", e.Until).Msg("SPECI")
+			effects = append(effects, e)
+		}
+		if e, ok := lightningEffect(c.Lightning, lightningFor(metar)); ok {
+			log.Debug().Str("station", metar.StationID).Str("wx", metar.WxString).Msg("Lightning")
+			effects = append(effects, e)
+		}
+		frame.Pixels = append(frame.Pixels, display.Pixel{Num: ledNum, Color: col, Effects: effects})
+	}
+	frame.Pixels = append(frame.Pixels, legendPixels(c, theme)...)
+	if p, ok := statusPixel(c, state, renderer.Heartbeat(), now); ok {
+		frame.Pixels = append(frame.Pixels, p)
+	}
+
+	if err := renderer.Submit(frame); err != nil {
+		log.Warn().Err(err).Msg("Stations mapped outside the LED strip")
 	}
 }
 
+// categoryColor returns the wind-adjusted flight category color for a METAR.
+func categoryColor(c config.Config, theme Theme, metar Metar) color.RGBA {
+	windKt, _ := strconv.ParseFloat(metar.WindSpeedKt, 64)
+	gustKt, _ := strconv.ParseFloat(metar.WindGustKt, 64)
+	effectiveKt := math.Max(windKt, gustKt)
+
+	log.Debug().
+		Str("station", metar.StationID).
+		Float64("windKt", windKt).
+		Float64("gustKt", gustKt).
+		Msg("Wind")
+
+	return theme.FlightColor(metar.FlightCategory, effectiveKt, c.WindLowKt, c.WindHighKt)
+}
+
 func parseMetarCSV(data []byte) (*[]Metar, error) {
 	s := string(data)
 	idx := strings.Index(s, "raw_text")
@@ -227,6 +250,9 @@ var metarBaseURL = "https://www.aviationweather.gov/api/data/dataserver"
 func fetchMetars(s map[int]string) ([]byte, error) {
 	var stations []string
 	for _, station := range s {
+		if !config.IsStation(station) {
+			continue
+		}
 		stations = append(stations, station)
 	}
 
@@ -6,6 +6,9 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+
 	"golang.org/x/image/colornames"
 )
 
@@ -78,9 +81,10 @@ func TestParseMetarCSV_Empty(t *testing.T) {
 	}
 }
 
-// flightCategoryToColor tests
+// Theme.CategoryColor tests
 
-func TestFlightCategoryToColor(t *testing.T) {
+func TestCategoryColor_DefaultTheme(t *testing.T) {
+	theme := ThemeFromConfig(config.ThemePresets["default"])
 	tests := []struct {
 		input string
 		want  color.RGBA
@@ -96,15 +100,15 @@ func TestFlightCategoryToColor(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
-			got := flightCategoryToColor(tt.input)
+			got := theme.CategoryColor(tt.input)
 			if got != tt.want {
-				t.Errorf("flightCategoryToColor(%q) = %+v, want %+v", tt.input, got, tt.want)
+				t.Errorf("CategoryColor(%q) = %+v, want %+v", tt.input, got, tt.want)
 			}
 		})
 	}
 }
 
-// blendColors and windAdjustedColor tests
+// BlendColors and windAdjustedColor tests
 
 func TestBlendColors(t *testing.T) {
 	white := color.RGBA{255, 255, 255, 255}
@@ -121,9 +125,9 @@ func TestBlendColors(t *testing.T) {
 		{2.0, white},  // clamped above 1
 	}
 	for _, tt := range tests {
-		got := blendColors(black, white, tt.t)
+		got := display.BlendColors(black, white, tt.t)
 		if got.R != tt.want.R || got.G != tt.want.G || got.B != tt.want.B {
-			t.Errorf("blendColors(black, white, %v) = %v, want %v", tt.t, got, tt.want)
+			t.Errorf("display.BlendColors(black, white, %v) = %v, want %v", tt.t, got, tt.want)
 		}
 	}
 }
@@ -142,11 +146,11 @@ func TestWindAdjustedColor(t *testing.T) {
 	}{
 		{"calm", 0, base, 0},
 		{"at low threshold", 10, base, 0},
-		{"just above low", 10.1, blendColors(base, windy, 0.1/15), 2},
-		{"midpoint", 17.5, blendColors(base, windy, 0.5), 2},
+		{"just above low", 10.1, display.BlendColors(base, windy, 0.1/15), 2},
+		{"midpoint", 17.5, display.BlendColors(base, windy, 0.5), 2},
 		{"at high threshold", 25, windy, 2},
-		{"above high, white blend", 40, blendColors(windy, white, 0.4), 2},
-		{"well above high, capped", 100, blendColors(windy, white, 0.4), 2},
+		{"above high, white blend", 40, display.BlendColors(windy, white, 0.4), 2},
+		{"well above high, capped", 100, display.BlendColors(windy, white, 0.4), 2},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -213,6 +217,31 @@ func TestFetchMetars_Success(t *testing.T) {
 	}
 }
 
+func TestFetchMetars_SkipsLegend(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("stationString")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	origURL := metarBaseURL
+	origClient := httpClient
+	metarBaseURL = srv.URL
+	httpClient = srv.Client()
+	defer func() {
+		metarBaseURL = origURL
+		httpClient = origClient
+	}()
+
+	if _, err := fetchMetars(map[int]string{0: "KOAK", 1: "LEGEND:VFR"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query != "KOAK" {
+		t.Errorf("stationString: got %q, want KOAK", query)
+	}
+}
+
 func TestFetchMetars_HTTPError(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -0,0 +1,76 @@
+package metardata
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Sensor status remarks reported by automated stations.
+var sensorRemarks = map[string]string{
+	"PWINO":  "present weather sensor off",
+	"TSNO":   "lightning sensor off",
+	"FZRANO": "freezing rain sensor off",
+	"VISNO":  "visibility sensor not operating",
+	"CHINO":  "ceiling sensor not operating",
+	"RVRNO":  "runway visual range not available",
+	"PNO":    "precipitation gauge not operating",
+}
+
+// DegradedReasons explains why a station's data may be untrustworthy: its
+// maintenance indicator is set, or it reports sensors that are off or not
+// operating. An empty result means no problems were reported.
+func (m Metar) DegradedReasons() []string {
+	var reasons []string
+
+	flags := []struct {
+		value  string
+		reason string
+	}{
+		{m.MaintenanceIndicatorOn, "maintenance indicator on"},
+		{m.NoSignal, "no signal"},
+		{m.LightningSensorOff, "lightning sensor off"},
+		{m.FreezingRainSensorOff, "freezing rain sensor off"},
+		{m.PresentWeatherSensorOff, "present weather sensor off"},
+	}
+	for _, f := range flags {
+		if strings.EqualFold(f.value, "TRUE") {
+			reasons = append(reasons, f.reason)
+		}
+	}
+
+	if idx := strings.Index(m.RawText, " RMK "); idx != -1 {
+		for _, tok := range strings.Fields(m.RawText[idx:]) {
+			reason, ok := sensorRemarks[tok]
+			if ok && !slices.Contains(reasons, reason) {
+				reasons = append(reasons, reason)
+			}
+		}
+	}
+	return reasons
+}
+
+// degradedEffect returns the cue for a station with degraded data, or false if
+// none should be shown.
+func degradedEffect(c config.DegradedConfig, m Metar) (display.Effect, bool) {
+	if c.Disabled {
+		return display.Effect{}, false
+	}
+	reasons := m.DegradedReasons()
+	if len(reasons) == 0 {
+		return display.Effect{}, false
+	}
+
+	e, err := display.EffectFromConfig(c.Effect)
+	if err != nil {
+		log.Warn().Err(err).Msg("Invalid degraded effect")
+		return display.Effect{}, false
+	}
+	e.Priority = priorityDegraded
+	log.Debug().Str("station", m.StationID).Strs("reasons", reasons).Msg("Degraded")
+	return e, true
+}
@@ -0,0 +1,60 @@
+package metardata
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+)
+
+func TestDegradedReasons(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Metar
+		want []string
+	}{
+		{"healthy", Metar{RawText: "KOAK 121853Z AUTO 27010KT 10SM CLR 15/10 A3001 RMK AO2"}, nil},
+		{"maintenance flag", Metar{MaintenanceIndicatorOn: "TRUE"}, []string{"maintenance indicator on"}},
+		{
+			"sensor flags",
+			Metar{NoSignal: "TRUE", LightningSensorOff: "TRUE", FreezingRainSensorOff: "TRUE", PresentWeatherSensorOff: "TRUE"},
+			[]string{"no signal", "lightning sensor off", "freezing rain sensor off", "present weather sensor off"},
+		},
+		{
+			"remarks",
+			Metar{RawText: "KO69 121853Z AUTO 27010KT 10SM CLR 15/10 A3001 RMK AO2 VISNO CHINO $"},
+			[]string{"visibility sensor not operating", "ceiling sensor not operating"},
+		},
+		{
+			"flag and remark not duplicated",
+			Metar{LightningSensorOff: "TRUE", RawText: "KO69 121853Z AUTO RMK AO2 TSNO"},
+			[]string{"lightning sensor off"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.DegradedReasons(); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDegradedEffect(t *testing.T) {
+	c := config.DegradedConfig{Effect: config.EffectConfig{Effect: "blip", Color: "#000000", Rate: 4, Intensity: 0.7}}
+
+	if _, ok := degradedEffect(c, Metar{}); ok {
+		t.Error("expected no effect for a healthy station")
+	}
+
+	e, ok := degradedEffect(c, Metar{MaintenanceIndicatorOn: "TRUE"})
+	if !ok || e.Kind != display.EffectBlip || e.Intensity != 0.7 {
+		t.Errorf("got %+v, %v", e, ok)
+	}
+
+	c.Disabled = true
+	if _, ok := degradedEffect(c, Metar{MaintenanceIndicatorOn: "TRUE"}); ok {
+		t.Error("expected no effect when disabled")
+	}
+}
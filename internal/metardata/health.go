@@ -0,0 +1,135 @@
+package metardata
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Health is the system condition shown on the STATUS LED.
+type Health int
+
+const (
+	HealthOK Health = iota
+	HealthFetchFailed
+	HealthNoNetwork
+	HealthStale
+	HealthReloaded
+	HealthRenderStalled
+)
+
+// renderStalledAfter is how long the display loop can go without a heartbeat
+// before it is reported as stalled.
+const renderStalledAfter = 30 * time.Second
+
+var healthNames = map[Health]string{
+	HealthOK:            "ok",
+	HealthFetchFailed:   "fetch_failed",
+	HealthNoNetwork:     "no_network",
+	HealthStale:         "stale",
+	HealthReloaded:      "reloaded",
+	HealthRenderStalled: "render_stalled",
+}
+
+func (h Health) String() string {
+	return healthNames[h]
+}
+
+// fetchHealth classifies the error from the last fetch. Errors reaching the
+// API, such as DNS failures, refused connections and timeouts, mean no network.
+func fetchHealth(err error) Health {
+	if err == nil {
+		return HealthOK
+	}
+	var (
+		dnsErr *net.DNSError
+		opErr  *net.OpError
+		netErr net.Error
+	)
+	if errors.As(err, &dnsErr) || errors.As(err, &opErr) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return HealthNoNetwork
+	}
+	return HealthFetchFailed
+}
+
+// Health returns the system condition at now, given the display loop's last
+// heartbeat. A stalled display loop is shown first, then a recent config reload.
+// The data is stale when no fetch has succeeded within staleAfter, whether
+// fetches are failing or stuck; before then a failing fetch shows its cause.
+func (s *State) Health(now, heartbeat time.Time, staleAfter, reloadedFor time.Duration) Health {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !heartbeat.IsZero() && now.Sub(heartbeat) > renderStalledAfter {
+		return HealthRenderStalled
+	}
+	if !s.reloaded.IsZero() && now.Sub(s.reloaded) < reloadedFor {
+		return HealthReloaded
+	}
+	last := s.fetched
+	if last.IsZero() {
+		last = s.started
+	}
+	if staleAfter > 0 && now.Sub(last) > staleAfter {
+		return HealthStale
+	}
+	return fetchHealth(s.fetchErr)
+}
+
+// recordFetch notes the outcome of a fetch for Health.
+func (s *State) recordFetch(err error, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetchErr = err
+	if err == nil {
+		s.fetched = now
+	}
+}
+
+// statusPixel returns the Pixel for the STATUS LED, or false if none is mapped.
+// Its pattern is animated by the display loop, so the heartbeat also stops if
+// rendering hangs; a stall is logged too, as the LED may not show it.
+func statusPixel(c config.Config, state *State, heartbeat, now time.Time) (display.Pixel, bool) {
+	staleAfter := time.Duration(c.StaleAfterMin) * time.Minute
+	reloadedFor := time.Duration(c.Status.ReloadedS) * time.Second
+	health := state.Health(now, heartbeat, staleAfter, reloadedFor)
+	if health == HealthRenderStalled {
+		log.Error().Time("heartbeat", heartbeat).Msg("Display loop has stalled")
+	}
+
+	num, ok := c.Stations[config.StatusLed]
+	if !ok {
+		return display.Pixel{}, false
+	}
+
+	ec := map[Health]config.EffectConfig{
+		HealthOK:            c.Status.Healthy,
+		HealthFetchFailed:   c.Status.FetchFailed,
+		HealthNoNetwork:     c.Status.NoNetwork,
+		HealthStale:         c.Status.Stale,
+		HealthReloaded:      c.Status.Reloaded,
+		HealthRenderStalled: c.Status.RenderStalled,
+	}[health]
+	e, err := display.EffectFromConfig(ec)
+	if err != nil {
+		log.Warn().Err(err).Stringer("health", health).Msg("Invalid status effect")
+		return display.Pixel{Num: num}, true
+	}
+	return display.Pixel{Num: num, Effects: []display.Effect{e}}, true
+}
+
+// renderStatus submits the STATUS LED on its own, between full renders.
+func renderStatus(c config.Config, renderer *display.Renderer, state *State) {
+	p, ok := statusPixel(c, state, renderer.Heartbeat(), time.Now())
+	if !ok {
+		return
+	}
+	if err := renderer.Submit(display.Frame{Pixels: []display.Pixel{p}}); err != nil {
+		log.Warn().Err(err).Msg("Status LED outside the LED strip")
+	}
+}
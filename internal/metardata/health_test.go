@@ -0,0 +1,111 @@
+package metardata
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+)
+
+func TestFetchHealth(t *testing.T) {
+	dns := &url.Error{Op: "Get", URL: "https://example.invalid", Err: &net.DNSError{Err: "no such host", Name: "example.invalid"}}
+	refused := &url.Error{Op: "Get", URL: "http://127.0.0.1:0", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+
+	tests := []struct {
+		name string
+		err  error
+		want Health
+	}{
+		{"ok", nil, HealthOK},
+		{"dns", dns, HealthNoNetwork},
+		{"refused", refused, HealthNoNetwork},
+		{"http status", fmt.Errorf("HTTP expected 200 got 500"), HealthFetchFailed},
+	}
+	for _, tt := range tests {
+		if got := fetchHealth(tt.err); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStateHealth(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewState(config.Config{})
+	s.started = start
+	stale, reload := 2*time.Hour, 15*time.Second
+	var beat time.Time // no heartbeat yet
+
+	if got := s.Health(start.Add(time.Minute), beat, stale, reload); got != HealthOK {
+		t.Errorf("before the first fetch: got %v, want ok", got)
+	}
+	if got := s.Health(start.Add(3*time.Hour), beat, stale, reload); got != HealthStale {
+		t.Errorf("no fetch for 3h: got %v, want stale", got)
+	}
+
+	s.recordFetch(nil, start.Add(3*time.Hour))
+	if got := s.Health(start.Add(3*time.Hour), beat, stale, reload); got != HealthOK {
+		t.Errorf("after a fetch: got %v, want ok", got)
+	}
+
+	s.recordFetch(errors.New("HTTP expected 200 got 503"), start.Add(4*time.Hour))
+	if got := s.Health(start.Add(4*time.Hour), beat, stale, reload); got != HealthFetchFailed {
+		t.Errorf("failing fetch: got %v, want fetch_failed", got)
+	}
+	if got := s.Health(start.Add(6*time.Hour), beat, stale, reload); got != HealthStale {
+		t.Errorf("failing past stale_after_min: got %v, want stale over the cause", got)
+	}
+
+	s.Reload(config.Config{}, start.Add(6*time.Hour))
+	if got := s.Health(start.Add(6*time.Hour+time.Second), beat, stale, reload); got != HealthReloaded {
+		t.Errorf("just reloaded: got %v, want reloaded", got)
+	}
+	if got := s.Health(start.Add(6*time.Hour+time.Minute), beat, stale, reload); got != HealthStale {
+		t.Errorf("after the reload window: got %v, want stale", got)
+	}
+}
+
+func TestStateHealth_Heartbeat(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewState(config.Config{})
+	s.started = now
+
+	if got := s.Health(now, now.Add(-time.Second), time.Hour, 0); got != HealthOK {
+		t.Errorf("recent heartbeat: got %v, want ok", got)
+	}
+	if got := s.Health(now, now.Add(-time.Minute), time.Hour, 0); got != HealthRenderStalled {
+		t.Errorf("no heartbeat for a minute: got %v, want render_stalled", got)
+	}
+	s.Reload(config.Config{}, now)
+	if got := s.Health(now, now.Add(-time.Minute), time.Hour, time.Minute); got != HealthRenderStalled {
+		t.Errorf("stalled after a reload: got %v, want render_stalled over reloaded", got)
+	}
+}
+
+func TestStatusPixel(t *testing.T) {
+	c := config.Config{Leds: map[int]string{0: "KSFO", 7: "status"}, StaleAfterMin: 120}
+	c.Stations = map[string]int{"KSFO": 0, "STATUS": 7}
+	c.Status.Healthy = config.EffectConfig{Effect: "breathe", Color: "#00ff00", Rate: 10, Intensity: 0.6}
+	c.Status.NoNetwork = config.EffectConfig{Effect: "blink", Color: "#ff0000", Rate: 60, Intensity: 1}
+	s := NewState(c)
+
+	p, ok := statusPixel(c, s, time.Time{}, time.Now())
+	if !ok || p.Num != 7 || len(p.Effects) != 1 || p.Effects[0].Kind != display.EffectBreathe {
+		t.Errorf("healthy: got %+v, %v", p, ok)
+	}
+
+	s.recordFetch(&net.DNSError{Err: "no such host"}, time.Now())
+	p, _ = statusPixel(c, s, time.Time{}, time.Now())
+	if len(p.Effects) != 1 || p.Effects[0].Kind != display.EffectBlink {
+		t.Errorf("no network: got %+v, want a blink", p)
+	}
+
+	delete(c.Stations, "STATUS")
+	if _, ok := statusPixel(c, s, time.Time{}, time.Now()); ok {
+		t.Error("expected no pixel without a STATUS LED")
+	}
+}
@@ -0,0 +1,46 @@
+package metardata
+
+import (
+	"image/color"
+	"maps"
+	"slices"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+
+	"github.com/rs/zerolog/log"
+)
+
+// legendPixels returns a Pixel for each legend LED in c.Leds showing the theme
+// color for its state, scaled by the legend brightness. With DisableAtNight the
+// color is held by a solid NightOff effect over a dark base, so the Animator
+// turns the legend off between sunset and sunrise. Unless DarkInQuietHours is
+// set, the pixels stay lit through quiet hours.
+func legendPixels(c config.Config, theme Theme) []display.Pixel {
+	var pixels []display.Pixel
+	quietOn := !c.Legend.DarkInQuietHours
+	for _, num := range slices.Sorted(maps.Keys(c.Leds)) {
+		key, ok := config.LegendKey(c.Leds[num])
+		if !ok {
+			continue
+		}
+		col, ok := theme.LegendColor(key)
+		if !ok {
+			log.Warn().Int("led", num).Str("legend", c.Leds[num]).Msg("Unknown legend entry")
+			continue
+		}
+		col = display.BlendColors(color.RGBA{}, col, c.Legend.Level())
+
+		if c.Legend.DisableAtNight {
+			pixels = append(pixels, display.Pixel{Num: num, QuietOn: quietOn, Effects: []display.Effect{{
+				Kind:      display.EffectSolid,
+				Color:     col,
+				Intensity: 1,
+				NightOff:  true,
+			}}})
+			continue
+		}
+		pixels = append(pixels, display.Pixel{Num: num, Color: col, QuietOn: quietOn})
+	}
+	return pixels
+}
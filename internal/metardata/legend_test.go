@@ -0,0 +1,90 @@
+package metardata
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+)
+
+func TestThemeLegendColor(t *testing.T) {
+	theme := ThemeFromConfig(config.ThemePresets["default"])
+	for _, key := range config.LegendKeys {
+		if _, ok := theme.LegendColor(key); !ok {
+			t.Errorf("LegendColor(%q): no color", key)
+		}
+	}
+	if got, _ := theme.LegendColor("WINDY_IFR"); got != theme.WindyColor("IFR") {
+		t.Errorf("WINDY_IFR: got %v, want the windy IFR color", got)
+	}
+	if _, ok := theme.LegendColor("SPECI"); ok {
+		t.Error("expected no color for an unknown key")
+	}
+}
+
+func legendLevel(v float64) *float64 { return &v }
+
+func TestLegendPixels(t *testing.T) {
+	theme := ThemeFromConfig(config.ThemeConfig{VFR: "#00ff00", WindyIFR: "#ff8000"})
+	c := config.Config{
+		Leds:   map[int]string{0: "KSFO", 3: "legend:windy_ifr", 1: "LEGEND:VFR"},
+		Legend: config.LegendConfig{Brightness: legendLevel(0.5)},
+	}
+
+	got := legendPixels(c, theme)
+	want := []display.Pixel{
+		{Num: 1, Color: color.RGBA{G: 0x7f, A: 0xff}},
+		{Num: 3, Color: color.RGBA{R: 0x7f, G: 0x40, A: 0xff}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].Num != want[i].Num || got[i].Color != want[i].Color || len(got[i].Effects) != 0 || !got[i].QuietOn {
+			t.Errorf("pixel %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLegendPixels_DisableAtNight(t *testing.T) {
+	theme := ThemeFromConfig(config.ThemeConfig{VFR: "#00ff00"})
+	c := config.Config{
+		Leds:   map[int]string{0: "LEGEND:VFR"},
+		Legend: config.LegendConfig{DisableAtNight: true},
+	}
+
+	got := legendPixels(c, theme)
+	if len(got) != 1 || len(got[0].Effects) != 1 {
+		t.Fatalf("got %+v, want one pixel with a solid effect", got)
+	}
+	e := got[0].Effects[0]
+	if got[0].Color != (color.RGBA{}) || e.Kind != display.EffectSolid || !e.NightOff || e.Color != theme.CategoryColor("VFR") {
+		t.Errorf("got %+v, want a dark base under a NightOff VFR effect", got[0])
+	}
+}
+
+func TestLegendPixels_DarkInQuietHours(t *testing.T) {
+	theme := ThemeFromConfig(config.ThemeConfig{VFR: "#00ff00"})
+	c := config.Config{
+		Leds:   map[int]string{0: "LEGEND:VFR"},
+		Legend: config.LegendConfig{DarkInQuietHours: true},
+	}
+
+	got := legendPixels(c, theme)
+	if len(got) != 1 || got[0].QuietOn {
+		t.Errorf("got %+v, want a pixel that fades out in quiet hours", got)
+	}
+}
+
+func TestLegendPixels_Off(t *testing.T) {
+	theme := ThemeFromConfig(config.ThemeConfig{VFR: "#00ff00"})
+	c := config.Config{
+		Leds:   map[int]string{1: "LEGEND:VFR"},
+		Legend: config.LegendConfig{Brightness: legendLevel(0)},
+	}
+	got := legendPixels(c, theme)
+	if len(got) != 1 || got[0].Color != (color.RGBA{}) {
+		t.Errorf("brightness 0: got %+v, want the legend dark", got)
+	}
+}
@@ -0,0 +1,118 @@
+package metardata
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/finack/twinkle/internal/config"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Matches ceiling-forming sky groups (BKN, OVC, OVX) and vertical visibility in the
+// body of a METAR, e.g. "BKN015" or "VV002". Heights are in hundreds of feet.
+var ceilingGroup = regexp.MustCompile(`\b(?:BKN|OVC|OVX|VV)(\d{3})\b`)
+
+// ceilingFt returns the lowest ceiling in a METAR, or false if there is none.
+// gocsv only fills the first of the repeated sky_cover columns, so the layers are
+// read from the raw text instead.
+func ceilingFt(m Metar) (float64, bool) {
+	body := m.RawText
+	if idx := strings.Index(body, " RMK "); idx != -1 {
+		body = body[:idx]
+	}
+
+	lowest := math.Inf(1)
+	for _, match := range ceilingGroup.FindAllStringSubmatch(body, -1) {
+		h, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			continue
+		}
+		lowest = math.Min(lowest, h*100)
+	}
+	if math.IsInf(lowest, 1) {
+		return 0, false
+	}
+	return lowest, true
+}
+
+// visibilitySM parses visibility_statute_mi, which reports "10+" for unlimited.
+func visibilitySM(m Metar) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(m.VisibilityStatuteMi, "+"), 64)
+	return v, err == nil
+}
+
+// crosswindKt returns the smallest crosswind component across a station's runways.
+// Variable or missing wind directions count the full wind as crosswind.
+func crosswindKt(m Metar, windKt float64, runways []float64) float64 {
+	dir, err := strconv.ParseFloat(m.WindDirDegrees, 64)
+	if err != nil || dir == 0 {
+		return windKt
+	}
+
+	lowest := windKt
+	for _, rwy := range runways {
+		xw := math.Abs(windKt * math.Sin((dir-rwy)*math.Pi/180))
+		lowest = math.Min(lowest, xw)
+	}
+	return lowest
+}
+
+// evaluateMinimums checks a METAR against a personal minimums profile and returns
+// the reasons it is a no-go; an empty result means go.
+func evaluateMinimums(p config.Profile, m Metar, runways []float64) []string {
+	var reasons []string
+
+	if p.CeilingFt > 0 {
+		if ceil, ok := ceilingFt(m); ok && ceil < p.CeilingFt {
+			reasons = append(reasons, fmt.Sprintf("ceiling %.0f ft below %.0f", ceil, p.CeilingFt))
+		}
+	}
+
+	if p.VisibilitySM > 0 {
+		vis, ok := visibilitySM(m)
+		switch {
+		case !ok:
+			reasons = append(reasons, "visibility missing")
+		case vis < p.VisibilitySM:
+			reasons = append(reasons, fmt.Sprintf("visibility %.1f SM below %.1f", vis, p.VisibilitySM))
+		}
+	}
+
+	windKt, _ := strconv.ParseFloat(m.WindSpeedKt, 64)
+	gustKt, _ := strconv.ParseFloat(m.WindGustKt, 64)
+
+	if p.WindKt > 0 && windKt > p.WindKt {
+		reasons = append(reasons, fmt.Sprintf("wind %.0f kt above %.0f", windKt, p.WindKt))
+	}
+	if p.GustKt > 0 && gustKt > p.GustKt {
+		reasons = append(reasons, fmt.Sprintf("gust %.0f kt above %.0f", gustKt, p.GustKt))
+	}
+	if p.CrosswindKt > 0 && len(runways) > 0 {
+		xw := crosswindKt(m, math.Max(windKt, gustKt), runways)
+		if xw > p.CrosswindKt {
+			reasons = append(reasons, fmt.Sprintf("crosswind %.0f kt above %.0f", xw, p.CrosswindKt))
+		}
+	}
+	return reasons
+}
+
+// minimumsColor returns the go or no-go color for a METAR under profile p.
+// Stations without a flight category have too little data to judge and show the
+// theme's stale color.
+func minimumsColor(p config.Profile, theme Theme, m Metar, runways []float64) color.RGBA {
+	switch strings.ToUpper(m.FlightCategory) {
+	case "", "NULL":
+		return theme.Stale
+	}
+
+	if reasons := evaluateMinimums(p, m, runways); len(reasons) > 0 {
+		log.Debug().Str("station", m.StationID).Strs("reasons", reasons).Msg("No-go")
+		return parseColor(p.NoGoColor)
+	}
+	return parseColor(p.GoColor)
+}
@@ -0,0 +1,131 @@
+package metardata
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/finack/twinkle/internal/config"
+	"golang.org/x/image/colornames"
+)
+
+func TestCeilingFt(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want float64
+		ok   bool
+	}{
+		{"KOAK 121853Z 27010KT 10SM FEW008 SCT020 BKN035 OVC100 15/10 A3001", 3500, true},
+		{"KSFO 121853Z 00000KT 1/4SM FG VV002 10/10 A3001", 200, true},
+		{"KSQL 121853Z 27010KT 10SM CLR 15/10 A3001", 0, false},
+		{"KSQL 121853Z 27010KT 10SM FEW020 15/10 A3001 RMK BKN005 NOT A CEILING", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ceilingFt(Metar{RawText: tt.raw})
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%q: got (%v, %v), want (%v, %v)", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCrosswindKt(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string
+		runways []float64
+		want    float64
+	}{
+		{"aligned", "280", []float64{280, 100}, 0},
+		{"direct crosswind", "190", []float64{280, 100}, 20},
+		{"picks best runway", "190", []float64{280, 190}, 0},
+		{"variable", "0", []float64{280}, 20},
+		{"missing", "", []float64{280}, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := crosswindKt(Metar{WindDirDegrees: tt.dir}, 20, tt.runways)
+			if got < tt.want-0.01 || got > tt.want+0.01 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateMinimums(t *testing.T) {
+	p := config.Profile{CeilingFt: 3000, VisibilitySM: 5, WindKt: 15, GustKt: 20, CrosswindKt: 10}
+	runways := []float64{280}
+
+	good := Metar{
+		RawText:             "KOAK 121853Z 28012KT 10SM BKN040 15/10 A3001",
+		VisibilityStatuteMi: "10+",
+		WindDirDegrees:      "280",
+		WindSpeedKt:         "12",
+	}
+	if reasons := evaluateMinimums(p, good, runways); len(reasons) != 0 {
+		t.Errorf("expected go, got %v", reasons)
+	}
+
+	bad := Metar{
+		RawText:             "KOAK 121853Z 19018G25KT 3SM BKN010 15/10 A3001",
+		VisibilityStatuteMi: "3",
+		WindDirDegrees:      "190",
+		WindSpeedKt:         "18",
+		WindGustKt:          "25",
+	}
+	if reasons := evaluateMinimums(p, bad, runways); len(reasons) != 5 {
+		t.Errorf("expected 5 no-go reasons, got %v", reasons)
+	}
+
+	noVis := good
+	noVis.VisibilityStatuteMi = ""
+	if reasons := evaluateMinimums(p, noVis, runways); len(reasons) != 1 {
+		t.Errorf("expected missing visibility to be a no-go, got %v", reasons)
+	}
+
+	// Crosswind is only checked when runway headings are configured.
+	if reasons := evaluateMinimums(config.Profile{CrosswindKt: 5}, bad, nil); len(reasons) != 0 {
+		t.Errorf("expected crosswind to be skipped without runways, got %v", reasons)
+	}
+}
+
+func TestMinimumsColor(t *testing.T) {
+	p := config.Profile{VisibilitySM: 5, GoColor: "#00ff00", NoGoColor: "#ff0000"}
+	green := color.RGBA{G: 0xff, A: 0xff}
+	red := color.RGBA{R: 0xff, A: 0xff}
+
+	if got := minimumsColor(p, Theme{}, Metar{FlightCategory: "VFR", VisibilityStatuteMi: "10+"}, nil); got != green {
+		t.Errorf("go: got %v, want %v", got, green)
+	}
+	if got := minimumsColor(p, Theme{}, Metar{FlightCategory: "MVFR", VisibilityStatuteMi: "4"}, nil); got != red {
+		t.Errorf("no-go: got %v, want %v", got, red)
+	}
+	theme := Theme{Stale: colornames.Slategray}
+	if got := minimumsColor(p, theme, Metar{}, nil); got != colornames.Slategray {
+		t.Errorf("unknown: got %v, want the theme's stale color", got)
+	}
+}
+
+func TestStateSetProfile(t *testing.T) {
+	s := NewState(config.Config{Profiles: map[string]config.Profile{
+		"ppl_day":      {},
+		"student_solo": {CeilingFt: 3000},
+	}})
+
+	if names := s.Profiles(); len(names) != 2 || names[0] != "ppl_day" {
+		t.Errorf("Profiles: got %v, want sorted names", names)
+	}
+	if err := s.SetProfile("student_solo"); err != nil {
+		t.Fatalf("SetProfile: %v", err)
+	}
+	if name, p := s.Profile(); name != "student_solo" || p.CeilingFt != 3000 {
+		t.Errorf("Profile: got %q %+v", name, p)
+	}
+	if len(s.refresh) != 1 {
+		t.Error("expected SetProfile to request a refresh")
+	}
+	if err := s.SetProfile("instrument"); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+	if err := s.SetProfile(""); err != nil {
+		t.Errorf("clearing profile: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package metardata
+
+import (
+	"math"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Intensity prefixes scale the configured effect intensity.
+var precipIntensityScale = map[string]float64{
+	"-": 0.6,
+	"":  1.0,
+	"+": 1.4,
+}
+
+// precipCodes returns the precipitation codes a weather group reports at the
+// station, with the intensity prefix that applies to them. Freezing phenomena
+// are reported both as "FZRA" and "RA" so either can be configured.
+func precipCodes(g WeatherGroup) []string {
+	if g.Vicinity {
+		return nil
+	}
+	var codes []string
+	for _, p := range g.Phenomena {
+		if g.Descriptor == "FZ" {
+			codes = append(codes, "FZ"+p)
+		}
+		codes = append(codes, p)
+	}
+	return codes
+}
+
+// precipFor returns the highest-priority precipitation code reported in a
+// METAR and its intensity prefix, or "" if none is configured.
+func precipFor(priority []string, m Metar) (code string, intensity string) {
+	reported := map[string]string{}
+	for _, g := range parseWxString(m.WxString) {
+		for _, c := range precipCodes(g) {
+			if _, ok := reported[c]; !ok {
+				reported[c] = g.Intensity
+			}
+		}
+	}
+	for _, c := range priority {
+		if i, ok := reported[c]; ok {
+			return c, i
+		}
+	}
+	return "", ""
+}
+
+// precipEffect returns the display effect for the highest-priority precipitation
+// reported in a METAR, or false if none should be shown.
+func precipEffect(c config.PrecipConfig, m Metar) (display.Effect, bool) {
+	if c.Disabled {
+		return display.Effect{}, false
+	}
+
+	code, intensity := precipFor(c.Priority, m)
+	ec, ok := c.Effects[code]
+	if !ok {
+		return display.Effect{}, false
+	}
+
+	e, err := display.EffectFromConfig(ec)
+	if err != nil {
+		log.Warn().Err(err).Str("code", code).Msg("Invalid precipitation effect")
+		return display.Effect{}, false
+	}
+	e.Intensity = math.Min(e.Intensity*precipIntensityScale[intensity], 1)
+	e.Priority = priorityPrecip
+	return e, true
+}
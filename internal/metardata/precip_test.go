@@ -0,0 +1,84 @@
+package metardata
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+)
+
+var testPriority = []string{"FZRA", "FZDZ", "PL", "GR", "SN", "RA"}
+
+func TestPrecipFor(t *testing.T) {
+	tests := []struct {
+		wx            string
+		wantCode      string
+		wantIntensity string
+	}{
+		{"", "", ""},
+		{"BR", "", ""},
+		{"-RA", "RA", "-"},
+		{"+SHSN", "SN", "+"},
+		{"-RA SN", "SN", ""},
+		{"FZRA", "FZRA", ""},
+		{"-FZDZ BR", "FZDZ", "-"},
+		{"RAPL", "PL", ""},
+		{"+TSRAGR", "GR", "+"},
+		{"VCSH", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wx, func(t *testing.T) {
+			code, intensity := precipFor(testPriority, Metar{WxString: tt.wx})
+			if code != tt.wantCode || intensity != tt.wantIntensity {
+				t.Errorf("got (%q, %q), want (%q, %q)", code, intensity, tt.wantCode, tt.wantIntensity)
+			}
+		})
+	}
+}
+
+func TestPrecipFor_FreezingFallsBackToBaseCode(t *testing.T) {
+	code, _ := precipFor([]string{"RA"}, Metar{WxString: "FZRA"})
+	if code != "RA" {
+		t.Errorf("got %q, want RA when FZRA is not prioritised", code)
+	}
+}
+
+func TestPrecipEffect(t *testing.T) {
+	c := config.PrecipConfig{
+		Priority: testPriority,
+		Effects: map[string]config.EffectConfig{
+			"SN":   {Effect: "twinkle", Color: "#c8dcff", Rate: 40, Intensity: 0.5},
+			"FZRA": {Effect: "pulse", Color: "#ff33cc", Rate: 30, Intensity: 0.8},
+			"RA":   {Effect: "bogus", Color: "#0000ff"},
+		},
+	}
+
+	e, ok := precipEffect(c, Metar{WxString: "-SN"})
+	if !ok {
+		t.Fatal("expected snow effect")
+	}
+	if e.Kind != display.EffectTwinkle || e.Color != (color.RGBA{R: 0xc8, G: 0xdc, B: 0xff, A: 0xff}) {
+		t.Errorf("snow: got %+v", e)
+	}
+	if e.Intensity != 0.5*0.6 {
+		t.Errorf("light snow intensity: got %v, want %v", e.Intensity, 0.5*0.6)
+	}
+
+	e, ok = precipEffect(c, Metar{WxString: "+FZRA"})
+	if !ok || e.Kind != display.EffectPulse || e.Intensity != 1 {
+		t.Errorf("heavy freezing rain: got %+v, %v (intensity should cap at 1)", e, ok)
+	}
+
+	if _, ok := precipEffect(c, Metar{WxString: "RA"}); ok {
+		t.Error("expected invalid effect config to be skipped")
+	}
+	if _, ok := precipEffect(c, Metar{WxString: "PL"}); ok {
+		t.Error("expected no effect for unmapped code")
+	}
+
+	c.Disabled = true
+	if _, ok := precipEffect(c, Metar{WxString: "SN"}); ok {
+		t.Error("expected no effect when disabled")
+	}
+}
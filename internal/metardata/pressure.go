@@ -0,0 +1,58 @@
+package metardata
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/image/colornames"
+)
+
+// parseColor parses a configured hex color, falling back to Antiquewhite so a bad
+// setting is visible on the map rather than fatal.
+func parseColor(hex string) color.RGBA {
+	col, err := config.ParseHexColor(hex)
+	if err != nil {
+		log.Warn().Err(err).Str("color", hex).Msg("Invalid color in config")
+		return colornames.Antiquewhite
+	}
+	return col
+}
+
+// pressureTendencyColor shades a station from the steady color toward the falling
+// or rising color as the three-hour tendency approaches MaxTendencyMb. Stations
+// that do not report a tendency show the theme's stale color.
+func pressureTendencyColor(c config.PressureConfig, theme Theme, m Metar) color.RGBA {
+	tendency, err := strconv.ParseFloat(m.ThreeHrPressureTendencyMb, 64)
+	if err != nil {
+		return theme.Stale
+	}
+
+	target := c.RisingColor
+	if tendency < 0 {
+		target = c.FallingColor
+	}
+	return display.BlendColors(parseColor(c.SteadyColor), parseColor(target), math.Abs(tendency)/c.MaxTendencyMb)
+}
+
+// altimeterColor flags stations with an altimeter setting below LowAltimeterInHg,
+// reaching full intensity AltimeterRangeInHg below the threshold. Stations that do
+// not report an altimeter setting show the theme's stale color.
+func altimeterColor(c config.PressureConfig, theme Theme, m Metar) color.RGBA {
+	altim, err := strconv.ParseFloat(m.AltimInHg, 64)
+	if err != nil {
+		return theme.Stale
+	}
+
+	steady := parseColor(c.SteadyColor)
+	if altim >= c.LowAltimeterInHg {
+		return steady
+	}
+	// Start partway in so a station just under the threshold is still distinct.
+	fraction := 0.3 + 0.7*(c.LowAltimeterInHg-altim)/c.AltimeterRangeInHg
+	return display.BlendColors(steady, parseColor(c.LowAltimeterColor), fraction)
+}
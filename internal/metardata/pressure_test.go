@@ -0,0 +1,74 @@
+package metardata
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/finack/twinkle/internal/config"
+	"golang.org/x/image/colornames"
+)
+
+var testPressure = config.PressureConfig{
+	MaxTendencyMb:      3,
+	FallingColor:       "#ff0000",
+	RisingColor:        "#0000ff",
+	SteadyColor:        "#000000",
+	LowAltimeterInHg:   29.70,
+	AltimeterRangeInHg: 0.5,
+	LowAltimeterColor:  "#ff00ff",
+}
+
+var testPressureTheme = Theme{Stale: colornames.Slategray}
+
+func TestPressureTendencyColor(t *testing.T) {
+	tests := []struct {
+		name     string
+		tendency string
+		want     color.RGBA
+	}{
+		{"missing", "", colornames.Slategray},
+		{"steady", "0.0", color.RGBA{A: 0xff}},
+		{"falling half", "-1.5", color.RGBA{R: 127, A: 0xff}},
+		{"falling rapidly", "-4.2", color.RGBA{R: 0xff, A: 0xff}},
+		{"rising full", "3.0", color.RGBA{B: 0xff, A: 0xff}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pressureTendencyColor(testPressure, testPressureTheme, Metar{ThreeHrPressureTendencyMb: tt.tendency})
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAltimeterColor(t *testing.T) {
+	if got := altimeterColor(testPressure, testPressureTheme, Metar{}); got != colornames.Slategray {
+		t.Errorf("missing: got %v, want the theme's stale color", got)
+	}
+	if got := altimeterColor(testPressure, testPressureTheme, Metar{AltimInHg: "30.01"}); got != (color.RGBA{A: 0xff}) {
+		t.Errorf("normal: got %v, want steady", got)
+	}
+
+	justBelow := altimeterColor(testPressure, testPressureTheme, Metar{AltimInHg: "29.65"})
+	deep := altimeterColor(testPressure, testPressureTheme, Metar{AltimInHg: "29.10"})
+	if justBelow.R == 0 || justBelow.R >= deep.R {
+		t.Errorf("expected deeper lows to be brighter: just below %v, deep %v", justBelow, deep)
+	}
+	if deep != (color.RGBA{R: 0xff, B: 0xff, A: 0xff}) {
+		t.Errorf("deep: got %v, want full low altimeter color", deep)
+	}
+}
+
+func TestStateSetMode(t *testing.T) {
+	s := NewState(config.Config{Mode: config.ModeCategory})
+	if err := s.SetMode(config.ModeAltimeter); err != nil {
+		t.Fatalf("SetMode: %v", err)
+	}
+	if s.Mode() != config.ModeAltimeter {
+		t.Errorf("Mode: got %q, want %q", s.Mode(), config.ModeAltimeter)
+	}
+	if err := s.SetMode("radar"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
@@ -0,0 +1,197 @@
+package metardata
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+)
+
+// State holds the most recently fetched METARs and the display settings that can
+// be changed at runtime. Changing a setting re-renders the map from the cached
+// METARs without waiting for the next fetch.
+type State struct {
+	mu       sync.RWMutex
+	metars   []Metar
+	changes  []Change
+	mode     string
+	profile  string
+	profiles map[string]config.Profile
+	theme    string
+	themes   map[string]Theme
+
+	// Fetch outcomes and reloads, for Health.
+	started  time.Time
+	fetched  time.Time
+	fetchErr error
+	reloaded time.Time
+
+	refresh chan struct{}
+}
+
+func NewState(c config.Config) *State {
+	return &State{
+		mode:     c.Mode,
+		profile:  c.Profile,
+		profiles: c.Profiles,
+		theme:    c.Theme,
+		themes:   themesFromConfig(c),
+		started:  time.Now(),
+		refresh:  make(chan struct{}, 1),
+	}
+}
+
+func themesFromConfig(c config.Config) map[string]Theme {
+	themes := make(map[string]Theme, len(c.Themes))
+	for name, tc := range c.Themes {
+		themes[name] = ThemeFromConfig(tc)
+	}
+	return themes
+}
+
+// Reload replaces the settings that can be changed at runtime, the mode,
+// profiles and themes, with those from a reloaded config. Other settings are
+// read once at startup.
+func (s *State) Reload(c config.Config, now time.Time) {
+	s.mu.Lock()
+	s.mode = c.Mode
+	s.profile = c.Profile
+	s.profiles = c.Profiles
+	s.theme = c.Theme
+	s.themes = themesFromConfig(c)
+	s.reloaded = now
+	s.mu.Unlock()
+
+	s.requestRefresh()
+}
+
+// Metars returns a copy of the most recently fetched METARs.
+func (s *State) Metars() []Metar {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Metar(nil), s.metars...)
+}
+
+// Changes returns a copy of the change log, oldest first.
+func (s *State) Changes() []Change {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Change(nil), s.changes...)
+}
+
+// setMetars replaces the cached METARs, keeping one report per station, and
+// appends any significant changes to the change log.
+func (s *State) setMetars(m []Metar, now time.Time) []Change {
+	latest := latestMetars(m)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes := diffMetars(s.metars, latest, now)
+	s.metars = latest
+	s.changes = append(s.changes, changes...)
+	if over := len(s.changes) - maxChanges; over > 0 {
+		s.changes = append([]Change(nil), s.changes[over:]...)
+	}
+	return changes
+}
+
+// Mode returns the selected display mode, one of config.Modes.
+func (s *State) Mode() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mode
+}
+
+// SetMode selects a display mode from config.Modes.
+func (s *State) SetMode(mode string) error {
+	if !slices.Contains(config.Modes, mode) {
+		return fmt.Errorf("unknown mode %q", mode)
+	}
+
+	s.mu.Lock()
+	s.mode = mode
+	s.mu.Unlock()
+
+	s.requestRefresh()
+	return nil
+}
+
+// Profile returns the selected personal minimums profile name and its limits.
+// An empty name means FAA flight categories are shown.
+func (s *State) Profile() (string, config.Profile) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.profile, s.profiles[s.profile]
+}
+
+// Profiles returns the configured profile names in sorted order.
+func (s *State) Profiles() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.profiles))
+	for name := range s.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetProfile selects a personal minimums profile; an empty name returns to FAA
+// flight categories.
+func (s *State) SetProfile(name string) error {
+	s.mu.Lock()
+	if _, ok := s.profiles[name]; name != "" && !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	s.profile = name
+	s.mu.Unlock()
+
+	s.requestRefresh()
+	return nil
+}
+
+// Theme returns the selected theme name and its colors.
+func (s *State) Theme() (string, Theme) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.theme, s.themes[s.theme]
+}
+
+// Themes returns the available theme names in sorted order.
+func (s *State) Themes() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.themes))
+	for name := range s.themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetTheme selects a theme by name.
+func (s *State) SetTheme(name string) error {
+	s.mu.Lock()
+	if _, ok := s.themes[name]; !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("unknown theme %q", name)
+	}
+	s.theme = name
+	s.mu.Unlock()
+
+	s.requestRefresh()
+	return nil
+}
+
+// requestRefresh asks the fetch routine to re-render; it never blocks.
+func (s *State) requestRefresh() {
+	select {
+	case s.refresh <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,83 @@
+package metardata
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/finack/twinkle/internal/config"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Theme is the set of colors used to show flight conditions.
+type Theme struct {
+	Category map[string]color.RGBA // by flight category
+	Windy    map[string]color.RGBA // by flight category
+	Unknown  color.RGBA            // an unrecognised flight category
+	Stale    color.RGBA            // no flight category, or a stale report
+}
+
+// ThemeFromConfig parses a theme's hex colors; invalid colors are logged and
+// shown as Antiquewhite.
+func ThemeFromConfig(tc config.ThemeConfig) Theme {
+	return Theme{
+		Category: map[string]color.RGBA{
+			"VFR":  parseColor(tc.VFR),
+			"MVFR": parseColor(tc.MVFR),
+			"IFR":  parseColor(tc.IFR),
+			"LIFR": parseColor(tc.LIFR),
+		},
+		Windy: map[string]color.RGBA{
+			"VFR":  parseColor(tc.WindyVFR),
+			"MVFR": parseColor(tc.WindyMVFR),
+			"IFR":  parseColor(tc.WindyIFR),
+			"LIFR": parseColor(tc.WindyLIFR),
+		},
+		Unknown: parseColor(tc.Unknown),
+		Stale:   parseColor(tc.Stale),
+	}
+}
+
+// CategoryColor returns the color for a flight category in calm wind.
+func (t Theme) CategoryColor(category string) color.RGBA {
+	category = strings.ToUpper(category)
+	if col, ok := t.Category[category]; ok {
+		return col
+	}
+	if category == "" || category == "NULL" {
+		return t.Stale
+	}
+	log.Warn().Str("flightCategory", category).Msg("Unknown flightCategory")
+	return t.Unknown
+}
+
+// WindyColor returns the "windy" variant of a flight category color.
+func (t Theme) WindyColor(category string) color.RGBA {
+	if col, ok := t.Windy[strings.ToUpper(category)]; ok {
+		return col
+	}
+	return t.Stale
+}
+
+// FlightColor returns the theme's color for a flight category, shifted toward its
+// windy variant, then white, as effectiveWindKt climbs past lowKt and highKt.
+func (t Theme) FlightColor(category string, effectiveWindKt, lowKt, highKt float64) color.RGBA {
+	return windAdjustedColor(t.CategoryColor(category), t.WindyColor(category), effectiveWindKt, lowKt, highKt)
+}
+
+// LegendColor returns the color shown by a legend LED for one of
+// config.LegendKeys, such as "IFR" or "WINDY_IFR".
+func (t Theme) LegendColor(key string) (color.RGBA, bool) {
+	switch key {
+	case "UNKNOWN":
+		return t.Unknown, true
+	case "STALE":
+		return t.Stale, true
+	}
+	if category, ok := strings.CutPrefix(key, "WINDY_"); ok {
+		col, ok := t.Windy[category]
+		return col, ok
+	}
+	col, ok := t.Category[key]
+	return col, ok
+}
@@ -0,0 +1,95 @@
+package metardata
+
+import (
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+
+	"golang.org/x/image/colornames"
+)
+
+func TestThemePresets(t *testing.T) {
+	for name, tc := range config.ThemePresets {
+		t.Run(name, func(t *testing.T) {
+			for _, hex := range []string{
+				tc.VFR, tc.MVFR, tc.IFR, tc.LIFR,
+				tc.WindyVFR, tc.WindyMVFR, tc.WindyIFR, tc.WindyLIFR,
+				tc.Unknown, tc.Stale,
+			} {
+				if _, err := config.ParseHexColor(hex); err != nil {
+					t.Errorf("color %q: %v", hex, err)
+				}
+			}
+
+			theme := ThemeFromConfig(tc)
+			seen := map[color.RGBA]string{}
+			for _, cat := range []string{"VFR", "MVFR", "IFR", "LIFR"} {
+				col := theme.CategoryColor(cat)
+				if other, ok := seen[col]; ok {
+					t.Errorf("%s and %s share %v", other, cat, col)
+				}
+				seen[col] = cat
+			}
+		})
+	}
+}
+
+func TestThemeFlightColor(t *testing.T) {
+	theme := ThemeFromConfig(config.ThemeConfig{
+		VFR: "#00ff00", WindyVFR: "#ffff00", Unknown: "#ffffff", Stale: "#101010",
+	})
+
+	if got := theme.FlightColor("vfr", 0, 10, 25); got != (color.RGBA{G: 0xff, A: 0xff}) {
+		t.Errorf("calm VFR: got %v", got)
+	}
+	if got := theme.FlightColor("VFR", 25, 10, 25); got != (color.RGBA{R: 0xff, G: 0xff, A: 0xff}) {
+		t.Errorf("windy VFR: got %v", got)
+	}
+	if got := theme.CategoryColor("NULL"); got != (color.RGBA{R: 0x10, G: 0x10, B: 0x10, A: 0xff}) {
+		t.Errorf("missing category: got %v, want stale", got)
+	}
+	if got := theme.CategoryColor("XYZ"); got != colornames.White {
+		t.Errorf("unrecognised category: got %v, want unknown", got)
+	}
+}
+
+func TestStateSetTheme(t *testing.T) {
+	c := config.Config{Theme: "default", Themes: config.ThemePresets}
+	s := NewState(c)
+
+	if names := s.Themes(); len(names) != len(config.ThemePresets) || names[0] != "default" {
+		t.Errorf("Themes: got %v, want sorted preset names", names)
+	}
+	if err := s.SetTheme("protanopia"); err != nil {
+		t.Fatalf("SetTheme: %v", err)
+	}
+	if name, theme := s.Theme(); name != "protanopia" || theme.CategoryColor("VFR") != parseColor(config.ThemePresets["protanopia"].VFR) {
+		t.Errorf("Theme: got %q %+v", name, theme)
+	}
+	if len(s.refresh) != 1 {
+		t.Error("expected SetTheme to request a refresh")
+	}
+	if err := s.SetTheme("neon"); err == nil {
+		t.Error("expected error for unknown theme")
+	}
+}
+
+func TestMetarIsStale(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := Metar{ObservationTime: "2026-03-01T09:53:00Z"}
+
+	if !m.isStale(now, 2*time.Hour) {
+		t.Error("report 2h07m old should be stale after 2h")
+	}
+	if m.isStale(now, 3*time.Hour) {
+		t.Error("report 2h07m old should not be stale after 3h")
+	}
+	if m.isStale(now, 0) {
+		t.Error("a zero max age should disable the check")
+	}
+	if (Metar{}).isStale(now, time.Minute) {
+		t.Error("a report without a time should not be stale")
+	}
+}
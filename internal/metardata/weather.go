@@ -0,0 +1,119 @@
+package metardata
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+
+	"golang.org/x/image/colornames"
+)
+
+// WeatherGroup is a single present-weather group from wx_string, e.g. "-TSRA" or "VCSH".
+type WeatherGroup struct {
+	Raw        string
+	Intensity  string // "-" light, "+" heavy, "" moderate
+	Vicinity   bool   // VC prefix: within 5-10 SM of the station
+	Descriptor string // MI, PR, BC, DR, BL, SH, TS or FZ
+	Phenomena  []string
+}
+
+var wxDescriptors = map[string]bool{
+	"MI": true, "PR": true, "BC": true, "DR": true,
+	"BL": true, "SH": true, "TS": true, "FZ": true,
+}
+
+// parseWxString splits a METAR present-weather string into its groups.
+func parseWxString(wx string) []WeatherGroup {
+	var groups []WeatherGroup
+	for _, raw := range strings.Fields(strings.ToUpper(wx)) {
+		g := WeatherGroup{Raw: raw}
+		tok := raw
+
+		if tok[0] == '-' || tok[0] == '+' {
+			g.Intensity = tok[:1]
+			tok = tok[1:]
+		}
+		if strings.HasPrefix(tok, "VC") {
+			g.Vicinity = true
+			tok = tok[2:]
+		}
+
+		for len(tok) >= 2 {
+			code := tok[:2]
+			tok = tok[2:]
+			if g.Descriptor == "" && len(g.Phenomena) == 0 && wxDescriptors[code] {
+				g.Descriptor = code
+				continue
+			}
+			g.Phenomena = append(g.Phenomena, code)
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+type lightningLevel int
+
+const (
+	lightningNone lightningLevel = iota
+	lightningDistant
+	lightningActive
+)
+
+// Matches remarks such as "LTG DSNT W", "LTGICCG VC NE" or "TS DSNT".
+var distantLightningRemark = regexp.MustCompile(`\b(LTG[A-Z]*|TS)\s+(DSNT|VC)\b`)
+
+// lightningFor classifies the lightning activity reported in a METAR's weather
+// groups and remarks.
+func lightningFor(m Metar) lightningLevel {
+	level := lightningNone
+	for _, g := range parseWxString(m.WxString) {
+		if g.Descriptor != "TS" {
+			continue
+		}
+		if !g.Vicinity {
+			return lightningActive
+		}
+		level = lightningDistant
+	}
+
+	if idx := strings.Index(m.RawText, " RMK "); idx != -1 {
+		if distantLightningRemark.MatchString(m.RawText[idx:]) {
+			level = lightningDistant
+		}
+	}
+	return level
+}
+
+// lightningEffect returns the display effect for a lightning level, or false if
+// none should be shown.
+func lightningEffect(c config.LightningConfig, level lightningLevel) (display.Effect, bool) {
+	if c.Disabled {
+		return display.Effect{}, false
+	}
+
+	switch level {
+	case lightningActive:
+		return display.Effect{
+			Kind:      display.EffectFlash,
+			Color:     colornames.White,
+			Rate:      c.FlashesPerMin,
+			Intensity: c.Intensity,
+			Priority:  priorityLightning,
+			NightOff:  c.DisableAtNight,
+		}, true
+	case lightningDistant:
+		return display.Effect{
+			Kind:      display.EffectFlicker,
+			Color:     colornames.White,
+			Rate:      c.DistantFlashesPerMin,
+			Intensity: c.DistantIntensity,
+			Priority:  priorityLightning,
+			NightOff:  c.DisableAtNight,
+		}, true
+	default:
+		return display.Effect{}, false
+	}
+}
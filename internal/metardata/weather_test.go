@@ -0,0 +1,98 @@
+package metardata
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+)
+
+func TestParseWxString(t *testing.T) {
+	groups := parseWxString("-TSRA BR VCSH +SN TS")
+	if len(groups) != 5 {
+		t.Fatalf("got %d groups, want 5", len(groups))
+	}
+
+	tests := []struct {
+		idx        int
+		intensity  string
+		vicinity   bool
+		descriptor string
+		phenomena  []string
+	}{
+		{0, "-", false, "TS", []string{"RA"}},
+		{1, "", false, "", []string{"BR"}},
+		{2, "", true, "SH", nil},
+		{3, "+", false, "", []string{"SN"}},
+		{4, "", false, "TS", nil},
+	}
+	for _, tt := range tests {
+		g := groups[tt.idx]
+		if g.Intensity != tt.intensity || g.Vicinity != tt.vicinity || g.Descriptor != tt.descriptor {
+			t.Errorf("[%d] %q: got %+v", tt.idx, g.Raw, g)
+		}
+		if !slices.Equal(g.Phenomena, tt.phenomena) {
+			t.Errorf("[%d] %q phenomena: got %v, want %v", tt.idx, g.Raw, g.Phenomena, tt.phenomena)
+		}
+	}
+}
+
+func TestParseWxString_Empty(t *testing.T) {
+	if groups := parseWxString(""); len(groups) != 0 {
+		t.Errorf("expected no groups, got %v", groups)
+	}
+}
+
+func TestLightningFor(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Metar
+		want lightningLevel
+	}{
+		{"none", Metar{WxString: "-RA BR", RawText: "KOAK 121853Z 27010KT 10SM -RA BR"}, lightningNone},
+		{"active", Metar{WxString: "+TSRA"}, lightningActive},
+		{"bare TS", Metar{WxString: "TS"}, lightningActive},
+		{"vicinity", Metar{WxString: "VCTS"}, lightningDistant},
+		{"active beats vicinity", Metar{WxString: "VCTS -TSRA"}, lightningActive},
+		{"distant remark", Metar{RawText: "KSAC 121853Z 18005KT 10SM FEW050 RMK AO2 LTG DSNT NE"}, lightningDistant},
+		{"distant remark with type", Metar{RawText: "KSAC 121853Z 18005KT RMK AO2 LTGICCG VC W"}, lightningDistant},
+		{"LTG outside remarks ignored", Metar{RawText: "KSAC LTG DSNT"}, lightningNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lightningFor(tt.m); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLightningEffect(t *testing.T) {
+	c := config.LightningConfig{
+		FlashesPerMin:        12,
+		Intensity:            1,
+		DistantFlashesPerMin: 6,
+		DistantIntensity:     0.3,
+		DisableAtNight:       true,
+	}
+
+	if _, ok := lightningEffect(c, lightningNone); ok {
+		t.Error("expected no effect for lightningNone")
+	}
+
+	e, ok := lightningEffect(c, lightningActive)
+	if !ok || e.Kind != display.EffectFlash || e.Rate != 12 || e.Intensity != 1 || !e.NightOff {
+		t.Errorf("active: got %+v, %v", e, ok)
+	}
+
+	e, ok = lightningEffect(c, lightningDistant)
+	if !ok || e.Kind != display.EffectFlicker || e.Rate != 6 || e.Intensity != 0.3 {
+		t.Errorf("distant: got %+v, %v", e, ok)
+	}
+
+	c.Disabled = true
+	if _, ok := lightningEffect(c, lightningActive); ok {
+		t.Error("expected no effect when disabled")
+	}
+}
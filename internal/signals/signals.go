@@ -10,7 +10,8 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func CatchSignals(stopMetarClient chan bool, stopLedUpdate chan bool, stopApplication chan bool) {
+// CatchSignals stops each routine in order on SIGINT or SIGTERM, then stopApplication.
+func CatchSignals(stopApplication chan bool, stopRoutines ...chan bool) {
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
@@ -18,10 +19,24 @@ func CatchSignals(stopMetarClient chan bool, stopLedUpdate chan bool, stopApplic
 		sig := <-sigs
 		log.Info().Str("signal", fmt.Sprintf("%v", sig)).Msg("Shutting down Twinkle!")
 		signal.Stop(sigs)
-		stopLedUpdate <- true
-		stopMetarClient <- true
+		for _, stop := range stopRoutines {
+			stop <- true
+		}
 		time.Sleep(time.Millisecond * 500)
 		stopApplication <- true
 		os.Exit(0)
 	}()
 }
+
+// CatchReload calls reload on each SIGHUP.
+func CatchReload(reload func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+
+	go func() {
+		for range sigs {
+			log.Info().Msg("Reloading configuration")
+			reload()
+		}
+	}()
+}
@@ -0,0 +1,167 @@
+package simulator
+
+import (
+	"image"
+	_ "image/jpeg" // background formats
+	_ "image/png"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/metardata"
+)
+
+// Default map size when there is no background image.
+const (
+	defaultWidth  = 1000
+	defaultHeight = 700
+	// margin keeps projected LEDs off the edge of the map, as a fraction of its size.
+	margin = 0.06
+)
+
+// Point is a position on the map in pixels from the top left.
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// LatLon is a station location in decimal degrees.
+type LatLon struct {
+	Lat float64
+	Lon float64
+}
+
+// Layout places each LED on the map.
+type Layout struct {
+	Width  int
+	Height int
+	Points []Point  // by LED number
+	Labels []string // station ID, or the LED number for unassigned LEDs
+}
+
+// NewLayout places ledCount LEDs. A station's configured x/y position is used
+// first, then its lat/lon projected into the map bounds, or, without bounds,
+// fitted to the area covered by all located stations. LEDs that can't be placed
+// are lined up along the bottom edge.
+func NewLayout(c config.SimulatorConfig, ledCount int, leds map[int]string, coords map[string]LatLon) Layout {
+	l := Layout{
+		Width:  c.Width,
+		Height: c.Height,
+		Points: make([]Point, ledCount),
+		Labels: make([]string, ledCount),
+	}
+	if l.Width == 0 || l.Height == 0 {
+		l.Width, l.Height = backgroundSize(c.Background)
+	}
+
+	project := fitProjection(l.Width, l.Height, leds, coords)
+	if b := c.Bounds; b.North != b.South && b.East != b.West {
+		project = boundsProjection(l.Width, l.Height, b)
+	}
+
+	var unplaced []int
+	for i := range l.Points {
+		station := strings.ToUpper(leds[i])
+		l.Labels[i] = station
+		if station == "" {
+			l.Labels[i] = strconv.Itoa(i)
+		}
+
+		if xy := c.Positions[station]; len(xy) == 2 {
+			l.Points[i] = Point{X: xy[0], Y: xy[1]}
+		} else if ll, ok := coords[station]; ok && project != nil {
+			l.Points[i] = project(ll)
+		} else {
+			unplaced = append(unplaced, i)
+		}
+	}
+
+	step := float64(l.Width) / float64(len(unplaced)+1)
+	for n, i := range unplaced {
+		l.Points[i] = Point{X: step * float64(n+1), Y: float64(l.Height) * (1 - margin/2)}
+	}
+	return l
+}
+
+// Coords collects the location of every station in the fetched METARs.
+func Coords(metars []metardata.Metar) map[string]LatLon {
+	coords := make(map[string]LatLon, len(metars))
+	for _, m := range metars {
+		lat, err1 := strconv.ParseFloat(m.Latitude, 64)
+		lon, err2 := strconv.ParseFloat(m.Longitude, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		coords[strings.ToUpper(m.StationID)] = LatLon{Lat: lat, Lon: lon}
+	}
+	return coords
+}
+
+// backgroundSize returns the size of the background image, or the default size
+// if there is none or it can't be read.
+func backgroundSize(path string) (int, int) {
+	if path == "" {
+		return defaultWidth, defaultHeight
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return defaultWidth, defaultHeight
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return defaultWidth, defaultHeight
+	}
+	return cfg.Width, cfg.Height
+}
+
+// boundsProjection maps lat/lon linearly onto a map whose edges are at b.
+func boundsProjection(width, height int, b config.BoundsConfig) func(LatLon) Point {
+	return func(ll LatLon) Point {
+		return Point{
+			X: (ll.Lon - b.West) / (b.East - b.West) * float64(width),
+			Y: (b.North - ll.Lat) / (b.North - b.South) * float64(height),
+		}
+	}
+}
+
+// fitProjection returns an equirectangular projection that fits the stations on
+// the map with their shape preserved, or nil if none of them have a location.
+func fitProjection(width, height int, leds map[int]string, coords map[string]LatLon) func(LatLon) Point {
+	var located []LatLon
+	for _, station := range leds {
+		if ll, ok := coords[strings.ToUpper(station)]; ok {
+			located = append(located, ll)
+		}
+	}
+	if len(located) == 0 {
+		return nil
+	}
+
+	minLat, maxLat := located[0].Lat, located[0].Lat
+	minLon, maxLon := located[0].Lon, located[0].Lon
+	for _, ll := range located[1:] {
+		minLat, maxLat = math.Min(minLat, ll.Lat), math.Max(maxLat, ll.Lat)
+		minLon, maxLon = math.Min(minLon, ll.Lon), math.Max(maxLon, ll.Lon)
+	}
+
+	// Shrink longitude by the cosine of the middle latitude so distances look right.
+	kx := math.Cos((minLat + maxLat) / 2 * math.Pi / 180)
+	spanX := math.Max((maxLon-minLon)*kx, 1e-6)
+	spanY := math.Max(maxLat-minLat, 1e-6)
+
+	w := float64(width) * (1 - 2*margin)
+	h := float64(height) * (1 - 2*margin)
+	scale := math.Min(w/spanX, h/spanY)
+	offX := (float64(width) - spanX*scale) / 2
+	offY := (float64(height) - spanY*scale) / 2
+
+	return func(ll LatLon) Point {
+		return Point{
+			X: offX + (ll.Lon-minLon)*kx*scale,
+			Y: offY + (maxLat-ll.Lat)*scale,
+		}
+	}
+}
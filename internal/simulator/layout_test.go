@@ -0,0 +1,75 @@
+package simulator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/metardata"
+)
+
+func near(a, b Point) bool {
+	return math.Abs(a.X-b.X) < 0.5 && math.Abs(a.Y-b.Y) < 0.5
+}
+
+func TestNewLayout_PositionsAndBounds(t *testing.T) {
+	c := config.SimulatorConfig{
+		Width:     1000,
+		Height:    500,
+		Bounds:    config.BoundsConfig{North: 38, South: 37, East: -122, West: -123},
+		Positions: map[string][]float64{"KSFO": {10, 20}},
+	}
+	leds := map[int]string{0: "KSFO", 1: "koak", 3: "KZZZ"}
+	coords := map[string]LatLon{
+		"KSFO": {Lat: 37.6, Lon: -122.4},
+		"KOAK": {Lat: 37.5, Lon: -122.25},
+	}
+
+	l := NewLayout(c, 4, leds, coords)
+
+	want := []Point{
+		{10, 20},   // configured position wins over coordinates
+		{750, 250}, // projected within bounds
+		{1000.0 / 3, 485},
+		{2000.0 / 3, 485}, // no coordinates: along the bottom
+	}
+	for i, p := range want {
+		if !near(l.Points[i], p) {
+			t.Errorf("LED %d at %+v, want %+v", i, l.Points[i], p)
+		}
+	}
+	if l.Labels[1] != "KOAK" || l.Labels[2] != "2" {
+		t.Errorf("labels: got %v", l.Labels)
+	}
+}
+
+func TestNewLayout_Fit(t *testing.T) {
+	leds := map[int]string{0: "KNW", 1: "KSE"}
+	coords := map[string]LatLon{
+		"KNW": {Lat: 1, Lon: 0},
+		"KSE": {Lat: 0, Lon: 1},
+	}
+
+	l := NewLayout(config.SimulatorConfig{}, 2, leds, coords)
+
+	if l.Width != defaultWidth || l.Height != defaultHeight {
+		t.Fatalf("size %dx%d, want default", l.Width, l.Height)
+	}
+	// A square area near the equator fits the height, centred horizontally.
+	side := defaultHeight * (1 - 2*margin)
+	left := (defaultWidth - side) / 2
+	top := defaultHeight * margin
+	if !near(l.Points[0], Point{left, top}) || !near(l.Points[1], Point{left + side, top + side}) {
+		t.Errorf("points %+v, want corners of a %.0f square at (%.0f, %.0f)", l.Points, side, left, top)
+	}
+}
+
+func TestCoords(t *testing.T) {
+	coords := Coords([]metardata.Metar{
+		{StationID: "KSFO", Latitude: "37.62", Longitude: "-122.37"},
+		{StationID: "KBAD", Latitude: "", Longitude: "-1"},
+	})
+	if len(coords) != 1 || coords["KSFO"] != (LatLon{Lat: 37.62, Lon: -122.37}) {
+		t.Errorf("got %v", coords)
+	}
+}
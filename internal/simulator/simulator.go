@@ -0,0 +1,231 @@
+// Package simulator draws the map in a browser, mirroring the physical LEDs.
+package simulator
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"maps"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+	"github.com/finack/twinkle/internal/metardata"
+
+	"github.com/rs/zerolog/log"
+)
+
+//go:embed map.html
+var mapPage []byte
+
+// eventInterval limits how often LED updates are streamed to each browser.
+const eventInterval = 50 * time.Millisecond
+
+type simulator struct {
+	config   config.SimulatorConfig
+	ledCount int
+	leds     map[int]string
+	state    *metardata.State
+	monitor  *display.Monitor
+}
+
+// Handler serves the simulator page at /map, drawing each LED at its position
+// over the optional background and updating live from monitor.
+func Handler(c config.Config, state *metardata.State, monitor *display.Monitor) http.Handler {
+	s := &simulator{
+		config:   c.Simulator,
+		ledCount: c.LedCount,
+		leds:     maps.Clone(c.Leds),
+		state:    state,
+		monitor:  monitor,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /map", s.getPage)
+	mux.HandleFunc("GET /map/layout", s.getLayout)
+	mux.HandleFunc("GET /map/background", s.getBackground)
+	mux.HandleFunc("GET /map/leds", s.getLeds)
+	mux.HandleFunc("GET /map/events", s.getEvents)
+	mux.HandleFunc("GET /map/snapshot.png", s.getPNG)
+	mux.HandleFunc("GET /map/snapshot.gif", s.getGIF)
+	return mux
+}
+
+func (s *simulator) layout() Layout {
+	return NewLayout(s.config, s.ledCount, s.leds, Coords(s.state.Metars()))
+}
+
+func (s *simulator) getPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(mapPage)
+}
+
+type ledLayout struct {
+	Label string `json:"label"`
+	Point
+}
+
+type layoutResponse struct {
+	Width      int         `json:"width"`
+	Height     int         `json:"height"`
+	Background bool        `json:"background"`
+	Leds       []ledLayout `json:"leds"`
+}
+
+func (s *simulator) getLayout(w http.ResponseWriter, r *http.Request) {
+	l := s.layout()
+	resp := layoutResponse{Width: l.Width, Height: l.Height, Background: s.config.Background != ""}
+	for i, p := range l.Points {
+		resp.Leds = append(resp.Leds, ledLayout{Label: l.Labels[i], Point: p})
+	}
+	writeJSON(w, resp)
+}
+
+func (s *simulator) getBackground(w http.ResponseWriter, r *http.Request) {
+	if s.config.Background == "" {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeFile(w, r, s.config.Background)
+}
+
+type ledsResponse struct {
+	Time       time.Time `json:"time"`
+	Colors     []string  `json:"colors"`     // #rrggbb before brightness
+	Brightness []int     `json:"brightness"` // 0-255
+}
+
+func newLedsResponse(snap display.Snapshot) ledsResponse {
+	resp := ledsResponse{Time: snap.Time, Brightness: snap.Brightness}
+	for _, c := range snap.Colors {
+		resp.Colors = append(resp.Colors, hexColor(c))
+	}
+	return resp
+}
+
+func (s *simulator) getLeds(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, newLedsResponse(s.monitor.Snapshot()))
+}
+
+// getEvents streams the LED state as server-sent events whenever it changes.
+func (s *simulator) getEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	updates, stop := s.monitor.Subscribe()
+	defer stop()
+
+	for {
+		data, err := json.Marshal(newLedsResponse(s.monitor.Snapshot()))
+		if err != nil {
+			log.Error().Err(err).Msg("Could not encode LED state")
+			return
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(eventInterval):
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-updates:
+		}
+	}
+}
+
+// options reads the labels, legend and brightness query parameters; labels and
+// the legend are shown unless turned off.
+func (s *simulator) options(r *http.Request) Options {
+	q := r.URL.Query()
+	flag := func(name string, def bool) bool {
+		v, err := strconv.ParseBool(q.Get(name))
+		if err != nil {
+			return def
+		}
+		return v
+	}
+
+	_, theme := s.state.Theme()
+	opts := DefaultOptions(s.config, theme)
+	opts.Labels = flag("labels", opts.Labels)
+	opts.Brightness = flag("brightness", opts.Brightness)
+	if !flag("legend", true) {
+		opts.Legend = nil
+	}
+	return opts
+}
+
+// DefaultOptions draws snapshots over the configured background with labels and
+// the theme's category legend.
+func DefaultOptions(c config.SimulatorConfig, theme metardata.Theme) Options {
+	opts := Options{Labels: true, Legend: CategoryLegend(theme)}
+	if c.Background != "" {
+		bg, err := loadImage(c.Background)
+		if err != nil {
+			log.Warn().Err(err).Str("background", c.Background).Msg("Could not load simulator background")
+		}
+		opts.Background = bg
+	}
+	return opts
+}
+
+func (s *simulator) getPNG(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "image/png")
+	if err := WritePNG(w, s.layout(), s.monitor.Snapshot(), s.options(r)); err != nil {
+		log.Error().Err(err).Msg("Could not write PNG snapshot")
+	}
+}
+
+// getGIF animates the LED states recorded over the last seconds, default 10.
+func (s *simulator) getGIF(w http.ResponseWriter, r *http.Request) {
+	seconds, err := strconv.Atoi(r.URL.Query().Get("seconds"))
+	if err != nil || seconds <= 0 {
+		seconds = 10
+	}
+	snaps := s.monitor.History(time.Now().Add(-time.Duration(seconds) * time.Second))
+	if len(snaps) == 0 {
+		snaps = []display.Snapshot{s.monitor.Snapshot()}
+	}
+
+	w.Header().Set("Content-Type", "image/gif")
+	if err := WriteGIF(w, s.layout(), snaps, s.options(r)); err != nil {
+		log.Error().Err(err).Msg("Could not write GIF snapshot")
+	}
+}
+
+func loadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error().Err(err).Msg("Could not encode HTTP response")
+	}
+}
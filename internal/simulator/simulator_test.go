@@ -0,0 +1,98 @@
+package simulator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/finack/twinkle/internal/config"
+	"github.com/finack/twinkle/internal/display"
+	"github.com/finack/twinkle/internal/metardata"
+)
+
+func newTestHandler() http.Handler {
+	c := config.Config{
+		LedCount: 2,
+		Leds:     map[int]string{0: "KSFO"},
+		Simulator: config.SimulatorConfig{
+			Width:     200,
+			Height:    100,
+			Positions: map[string][]float64{"KSFO": {50, 40}},
+		},
+		Hardware: config.HardwareConfig{
+			Channels: []config.ChannelConfig{{LedCount: 2, Brightness: 128}},
+			Segments: []config.SegmentConfig{{Channel: 0, Count: 2}},
+		},
+	}
+	return Handler(c, metardata.NewState(c), display.NewMonitor(c.Hardware, time.Minute))
+}
+
+func TestGetLayout(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/map/layout", nil))
+
+	var resp layoutResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Width != 200 || resp.Background || len(resp.Leds) != 2 {
+		t.Fatalf("got %+v", resp)
+	}
+	if l := resp.Leds[0]; l.Label != "KSFO" || l.X != 50 || l.Y != 40 {
+		t.Errorf("LED 0: got %+v", l)
+	}
+}
+
+func TestGetPageAndBackground(t *testing.T) {
+	h := newTestHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/map", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "/map/events") {
+		t.Errorf("page: status %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/map/background", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("background without one configured: got %d, want 404", rec.Code)
+	}
+}
+
+func TestGetEvents(t *testing.T) {
+	srv := httptest.NewServer(newTestHandler())
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/map/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var leds ledsResponse
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &leds); err != nil {
+		t.Fatalf("%q: %v", line, err)
+	}
+	if len(leds.Colors) != 2 || leds.Colors[0] != "#000000" || leds.Brightness[1] != 128 {
+		t.Errorf("got %+v", leds)
+	}
+}
+
+func TestHexColor(t *testing.T) {
+	if got := hexColor(color.RGBA{R: 0x32, G: 0xcd, B: 0x32}); got != "#32cd32" {
+		t.Errorf("got %q", got)
+	}
+}
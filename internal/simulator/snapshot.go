@@ -0,0 +1,184 @@
+package simulator
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+	"time"
+
+	"github.com/finack/twinkle/internal/display"
+	"github.com/finack/twinkle/internal/metardata"
+
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// gifFrameInterval is the shortest time between frames of an animated GIF;
+// snapshots recorded closer together are skipped.
+const gifFrameInterval = 100 * time.Millisecond
+
+// LegendEntry explains one LED color in a snapshot's legend.
+type LegendEntry struct {
+	Label string
+	Color color.RGBA
+}
+
+// CategoryLegend describes the flight category colors of a theme.
+func CategoryLegend(theme metardata.Theme) []LegendEntry {
+	var legend []LegendEntry
+	for _, cat := range []string{"VFR", "MVFR", "IFR", "LIFR"} {
+		legend = append(legend, LegendEntry{Label: cat, Color: theme.CategoryColor(cat)})
+	}
+	return append(legend, LegendEntry{Label: "No report", Color: theme.Stale})
+}
+
+// Options controls how snapshots are drawn.
+type Options struct {
+	Background image.Image // scaled to the layout; nil draws a dark map
+	Labels     bool        // station IDs under each LED
+	Legend     []LegendEntry
+	Brightness bool // dim each LED by its brightness, as on the strip
+}
+
+// RenderImage draws the LEDs of snap at their places in l.
+func RenderImage(l Layout, snap display.Snapshot, opts Options) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, l.Width, l.Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 0x11, G: 0x11, B: 0x11, A: 0xff}), image.Point{}, draw.Src)
+	if opts.Background != nil {
+		draw.ApproxBiLinear.Scale(img, img.Bounds(), opts.Background, opts.Background.Bounds(), draw.Over, nil)
+	}
+
+	radius := math.Max(4, float64(min(l.Width, l.Height))/90)
+	for i, p := range l.Points {
+		if i >= len(snap.Colors) {
+			break
+		}
+		c := snap.Colors[i]
+		if opts.Brightness && i < len(snap.Brightness) {
+			c = scaleColor(c, snap.Brightness[i])
+		}
+		drawLed(img, p, radius, c)
+		if opts.Labels {
+			drawLabel(img, l.Labels[i], int(p.X), int(p.Y+radius*3)+10)
+		}
+	}
+
+	if len(opts.Legend) > 0 {
+		drawLegend(img, opts.Legend)
+	}
+	return img
+}
+
+// WritePNG renders snap as a PNG.
+func WritePNG(w io.Writer, l Layout, snap display.Snapshot, opts Options) error {
+	return png.Encode(w, RenderImage(l, snap, opts))
+}
+
+// WriteGIF renders the snapshots as an animated GIF, holding each frame until
+// the time of the next.
+func WriteGIF(w io.Writer, l Layout, snaps []display.Snapshot, opts Options) error {
+	var kept []display.Snapshot
+	for _, s := range snaps {
+		if len(kept) > 0 && s.Time.Sub(kept[len(kept)-1].Time) < gifFrameInterval {
+			kept[len(kept)-1] = s
+			continue
+		}
+		kept = append(kept, s)
+	}
+
+	anim := &gif.GIF{}
+	for i, s := range kept {
+		src := RenderImage(l, s, opts)
+		frame := image.NewPaletted(src.Bounds(), palette.Plan9)
+		draw.Draw(frame, frame.Bounds(), src, image.Point{}, draw.Src)
+
+		delay := 100 // hold the last frame for a second
+		if i+1 < len(kept) {
+			delay = max(int(kept[i+1].Time.Sub(s.Time)/(10*time.Millisecond)), 2)
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+func scaleColor(c color.RGBA, brightness int) color.RGBA {
+	k := float64(brightness) / 255
+	return color.RGBA{
+		R: uint8(float64(c.R) * k),
+		G: uint8(float64(c.G) * k),
+		B: uint8(float64(c.B) * k),
+		A: 0xff,
+	}
+}
+
+// drawLed draws a solid dot of color c with a glow fading out to three times
+// its radius.
+func drawLed(img *image.RGBA, p Point, radius float64, c color.RGBA) {
+	glow := radius * 3
+	b := image.Rect(int(p.X-glow), int(p.Y-glow), int(p.X+glow)+1, int(p.Y+glow)+1).Intersect(img.Bounds())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			d := math.Hypot(float64(x)-p.X, float64(y)-p.Y)
+			var a float64
+			switch {
+			case d <= radius:
+				a = 1
+			case d < glow:
+				a = 0.5 * (1 - (d-radius)/(glow-radius))
+			default:
+				continue
+			}
+			img.SetRGBA(x, y, display.BlendColors(img.RGBAAt(x, y), c, a))
+		}
+	}
+}
+
+// drawLabel writes s centred on x with its baseline at y, outlined for
+// legibility over a background.
+func drawLabel(img *image.RGBA, s string, x, y int) {
+	face := basicfont.Face7x13
+	x -= font.MeasureString(face, s).Round() / 2
+	d := &font.Drawer{Dst: img, Face: face, Src: image.NewUniform(color.Black)}
+	for _, off := range []image.Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		d.Dot = fixed.P(x+off.X, y+off.Y)
+		d.DrawString(s)
+	}
+	d.Src = image.NewUniform(colornames.Whitesmoke)
+	d.Dot = fixed.P(x, y)
+	d.DrawString(s)
+}
+
+// drawLegend lists the legend entries with color swatches in the bottom left.
+func drawLegend(img *image.RGBA, legend []LegendEntry) {
+	const (
+		pad  = 8
+		line = 16
+	)
+	width := 0
+	for _, e := range legend {
+		width = max(width, font.MeasureString(basicfont.Face7x13, e.Label).Round())
+	}
+	box := image.Rect(pad, img.Bounds().Dy()-pad-len(legend)*line-pad, pad+pad+12+6+width+pad, img.Bounds().Dy()-pad)
+	draw.Draw(img, box, image.NewUniform(color.RGBA{A: 0xb0}), image.Point{}, draw.Over)
+
+	for i, e := range legend {
+		top := box.Min.Y + pad + i*line
+		swatch := image.Rect(box.Min.X+pad, top+1, box.Min.X+pad+12, top+13)
+		draw.Draw(img, swatch, image.NewUniform(e.Color), image.Point{}, draw.Src)
+		d := &font.Drawer{
+			Dst:  img,
+			Face: basicfont.Face7x13,
+			Src:  image.NewUniform(colornames.Whitesmoke),
+			Dot:  fixed.P(swatch.Max.X+6, top+11),
+		}
+		d.DrawString(e.Label)
+	}
+}
@@ -0,0 +1,183 @@
+package simulator
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/finack/twinkle/internal/display"
+
+	"golang.org/x/image/colornames"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden snapshots in testdata")
+
+var (
+	testLayout = Layout{
+		Width:  200,
+		Height: 100,
+		Points: []Point{{50, 30}, {150, 30}},
+		Labels: []string{"KSFO", "KOAK"},
+	}
+	testSnapshot = display.Snapshot{
+		Colors:     []color.RGBA{colornames.Limegreen, colornames.Red},
+		Brightness: []int{255, 51},
+	}
+)
+
+func TestRenderImage(t *testing.T) {
+	img := RenderImage(testLayout, testSnapshot, Options{})
+	if got := img.RGBAAt(50, 30); got != colornames.Limegreen {
+		t.Errorf("LED 0 center: got %v, want Limegreen", got)
+	}
+	if got := img.RGBAAt(150, 30); got != colornames.Red {
+		t.Errorf("LED 1 center without brightness: got %v, want Red", got)
+	}
+	if got := img.RGBAAt(190, 90); got != (color.RGBA{R: 0x11, G: 0x11, B: 0x11, A: 0xff}) {
+		t.Errorf("empty map: got %v", got)
+	}
+
+	img = RenderImage(testLayout, testSnapshot, Options{Brightness: true})
+	if got := img.RGBAAt(150, 30); got != (color.RGBA{R: 51, A: 0xff}) {
+		t.Errorf("LED 1 center at brightness 51: got %v", got)
+	}
+}
+
+func TestRenderImage_Golden(t *testing.T) {
+	img := RenderImage(testLayout, testSnapshot, Options{
+		Labels:     true,
+		Legend:     []LegendEntry{{Label: "VFR", Color: colornames.Limegreen}, {Label: "IFR", Color: colornames.Red}},
+		Brightness: true,
+	})
+	compareGolden(t, "snapshot", img)
+}
+
+// compareGolden checks img against testdata/<name>.golden.png, rewriting it
+// instead with -update.
+func compareGolden(t *testing.T, name string, img *image.RGBA) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden.png")
+	if *update {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	defer f.Close()
+	golden, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if golden.Bounds() != img.Bounds() {
+		t.Fatalf("size: got %v, golden %v", img.Bounds(), golden.Bounds())
+	}
+	var diff int
+	var first image.Point
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			if color.RGBAModel.Convert(golden.At(x, y)) != img.RGBAAt(x, y) {
+				if diff == 0 {
+					first = image.Pt(x, y)
+				}
+				diff++
+			}
+		}
+	}
+	if diff > 0 {
+		t.Errorf("%d pixels differ from %s, first at %v: got %v, golden %v",
+			diff, path, first, img.RGBAAt(first.X, first.Y), golden.At(first.X, first.Y))
+	}
+}
+
+func TestRenderImage_LabelsAndLegend(t *testing.T) {
+	plain := RenderImage(testLayout, testSnapshot, Options{})
+	img := RenderImage(testLayout, testSnapshot, Options{
+		Labels: true,
+		Legend: []LegendEntry{{Label: "VFR", Color: colornames.Limegreen}},
+	})
+
+	labelArea := image.Rect(30, 45, 70, 65)
+	if sameIn(plain, img, labelArea) {
+		t.Error("no label drawn under LED 0")
+	}
+	// The single swatch sits inside the legend box in the bottom left.
+	if got := img.RGBAAt(20, 78); got != colornames.Limegreen {
+		t.Errorf("legend swatch: got %v, want Limegreen", got)
+	}
+}
+
+func sameIn(a, b *image.RGBA, r image.Rectangle) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if a.RGBAAt(x, y) != b.RGBAAt(x, y) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestWriteGIF(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	snap := func(offset time.Duration, c color.RGBA) display.Snapshot {
+		return display.Snapshot{Time: start.Add(offset), Colors: []color.RGBA{c, c}, Brightness: []int{255, 255}}
+	}
+	snaps := []display.Snapshot{
+		snap(0, colornames.Red),
+		snap(20*time.Millisecond, colornames.Blue), // replaces the frame before it
+		snap(500*time.Millisecond, colornames.Limegreen),
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGIF(&buf, testLayout, snaps, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anim.Image) != 2 {
+		t.Fatalf("got %d frames, want 2", len(anim.Image))
+	}
+	if anim.Delay[0] != 48 || anim.Delay[1] != 100 {
+		t.Errorf("delays: got %v, want [48 100]", anim.Delay)
+	}
+	if r, g, b, _ := anim.Image[0].At(50, 30).RGBA(); r>>8 > 0x20 || g>>8 > 0x20 || b>>8 < 0xe0 {
+		t.Errorf("first frame: got %v, want blue", anim.Image[0].At(50, 30))
+	}
+}
+
+func TestGetSnapshotPNG(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/map/snapshot.png?legend=false", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("Content-Type: got %q", ct)
+	}
+	img, err := png.Decode(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 200 || b.Dy() != 100 {
+		t.Errorf("size: got %v, want 200x100", b)
+	}
+}